@@ -3,6 +3,6 @@ module notify
 go 1.25.5
 
 require (
-	github.com/lmittmann/tint v1.1.2 // indirect
-	golang.org/x/time v0.14.0 // indirect
+	github.com/lmittmann/tint v1.1.2
+	golang.org/x/time v0.14.0
 )
@@ -1,9 +1,14 @@
 package config
 
 import (
+	"encoding/json"
 	"fmt"
+	"net/http"
+	"net/url"
 	"os"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -11,7 +16,22 @@ type Config struct {
 	Server   ServerConfig
 	Feishu   FeishuConfig
 	Telegram TelegramConfig
+	Slack    SlackConfig
+	Discord  DiscordConfig
+	DingTalk DingTalkConfig
+	WeCom    WeComConfig
+	Email    EmailConfig
+	Teams    TeamsConfig
+	Ntfy     NtfyConfig
+	Gotify   GotifyConfig
+	Bark     BarkConfig
+	Pushover PushoverConfig
+	Matrix   MatrixConfig
+	Outbound OutboundConfig
 	Queue    QueueConfig
+	Auth     AuthConfig
+	Webhooks WebhooksConfig
+	Log      LogConfig
 }
 
 type ServerConfig struct {
@@ -29,12 +49,326 @@ type TelegramConfig struct {
 	BotToken string
 }
 
+// SlackConfig enables the bot API when BotToken is set and incoming webhooks
+// for the named Webhooks, which are addressed by name as the target.
+type SlackConfig struct {
+	BotToken string
+	Webhooks map[string]string
+	APIURL   string
+}
+
+func (c SlackConfig) Enabled() bool {
+	return c.BotToken != "" || len(c.Webhooks) > 0
+}
+
+// DiscordConfig enables the bot API when BotToken is set. Webhooks maps names
+// to webhook URLs; setting it, even to {}, also enables webhook targets given
+// as "id/token" or a full URL.
+type DiscordConfig struct {
+	BotToken string
+	Webhooks map[string]string
+	APIURL   string
+}
+
+func (c DiscordConfig) Enabled() bool {
+	return c.BotToken != "" || c.Webhooks != nil
+}
+
+// DingTalkConfig maps robot names, used as targets, to custom robots.
+type DingTalkConfig struct {
+	Robots map[string]DingTalkRobot
+	APIURL string
+}
+
+// DingTalkRobot is a custom robot's access token and, when the robot uses
+// signed requests, its SEC secret.
+type DingTalkRobot struct {
+	AccessToken string `json:"accessToken"`
+	Secret      string `json:"secret,omitempty"`
+}
+
+// WeComConfig enables application messages when CorpID is set and group
+// robots for the named Robots, which map names used as targets to webhook
+// keys.
+type WeComConfig struct {
+	CorpID     string
+	CorpSecret string
+	AgentID    int
+	Robots     map[string]string
+	APIURL     string
+}
+
+func (c WeComConfig) Enabled() bool {
+	return c.CorpID != "" || len(c.Robots) > 0
+}
+
+// EmailConfig enables SMTP delivery when Host is set. StartTLS requires the
+// server to offer STARTTLS before any credentials or mail are sent.
+// AllowedRecipients lists addresses and "@domain" entries mail may be sent
+// to; empty allows any recipient.
+type EmailConfig struct {
+	Host              string
+	Port              int
+	Username          string
+	Password          string
+	From              string
+	StartTLS          bool
+	AllowedRecipients []string
+}
+
+func (c EmailConfig) Enabled() bool {
+	return c.Host != ""
+}
+
+// TeamsConfig maps names, used as targets, to Teams Workflows or incoming
+// webhook URLs.
+type TeamsConfig struct {
+	Webhooks map[string]string
+}
+
+func (c TeamsConfig) Enabled() bool {
+	return len(c.Webhooks) > 0
+}
+
+// NtfyConfig enables ntfy when ServerURL is set. Targets are topics; Token
+// is an optional access token.
+type NtfyConfig struct {
+	ServerURL string
+	Token     string
+}
+
+// GotifyConfig maps names, used as targets, to Gotify application tokens on
+// the server at ServerURL.
+type GotifyConfig struct {
+	ServerURL string
+	Apps      map[string]string
+}
+
+// BarkConfig maps names to Bark device keys. Setting Devices, even to {},
+// also allows device keys as targets.
+type BarkConfig struct {
+	ServerURL string
+	Devices   map[string]string
+}
+
+// PushoverConfig enables Pushover when AppToken is set. Targets are user or
+// group keys.
+type PushoverConfig struct {
+	AppToken string
+	APIURL   string
+}
+
+// MatrixConfig enables Matrix when AccessToken is set.
+type MatrixConfig struct {
+	HomeserverURL string
+	AccessToken   string
+}
+
+// OutboundConfig maps endpoint names, used as targets of the webhook
+// channel, to HTTP receivers.
+type OutboundConfig struct {
+	Endpoints map[string]WebhookEndpoint
+}
+
+// WebhookEndpoint describes one outbound webhook. Template is a text/template
+// for the request body; the message is sent as JSON when it is empty. With a
+// Secret, requests carry an HMAC-SHA256 signature of "timestamp:body".
+type WebhookEndpoint struct {
+	URL             string            `json:"url"`
+	Method          string            `json:"method,omitempty"`
+	Headers         map[string]string `json:"headers,omitempty"`
+	Template        string            `json:"template,omitempty"`
+	Secret          string            `json:"secret,omitempty"`
+	SignatureHeader string            `json:"signatureHeader,omitempty"`
+	TimestampHeader string            `json:"timestampHeader,omitempty"`
+}
+
+// LogConfig controls what request data reaches the logs.
+type LogConfig struct {
+	// Bodies logs request bodies at debug level, after redaction.
+	Bodies bool
+	// RedactPatterns are regular expressions scrubbed from every log record
+	// in addition to the built-in patterns.
+	RedactPatterns []string
+}
+
+// API key scopes.
+const (
+	ScopeSend  = "send"  // POST /api/messages and webhooks, GET /api/tasks
+	ScopeRaw   = "raw"   // POST /api/messages/raw
+	ScopeChats = "chats" // GET /api/chats
+	ScopeAdmin = "admin" // scheduled tasks, dead letters and breakers
+)
+
+// AuthConfig lists the accepted API keys. Authentication is disabled when no
+// keys are configured.
+type AuthConfig struct {
+	APIKeys []APIKey
+}
+
+// APIKey grants its scopes for the listed channels and targets; an empty list
+// or "*" allows any.
+type APIKey struct {
+	Name     string   `json:"name"`
+	Key      string   `json:"key"`
+	Scopes   []string `json:"scopes"`
+	Channels []string `json:"channels,omitempty"`
+	Targets  []string `json:"targets,omitempty"`
+}
+
+func (k *APIKey) HasScope(scope string) bool {
+	return slices.Contains(k.Scopes, scope)
+}
+
+func (k *APIKey) AllowsChannel(channel string) bool {
+	return matchAny(k.Channels, channel)
+}
+
+// Allows reports whether the key may address target on channel.
+func (k *APIKey) Allows(channel, target string) bool {
+	return k.AllowsChannel(channel) && matchAny(k.Targets, target)
+}
+
+func matchAny(patterns []string, value string) bool {
+	return len(patterns) == 0 || slices.Contains(patterns, "*") || slices.Contains(patterns, value)
+}
+
+// SignatureConfig enables HMAC-SHA256 verification of an inbound webhook.
+// The signature covers "timestamp:body", as Grafana computes it when a
+// timestamp header is configured; verification is off without a secret.
+type SignatureConfig struct {
+	Secret          string
+	Header          string
+	TimestampHeader string
+	Tolerance       time.Duration
+}
+
+type WebhooksConfig struct {
+	Grafana SignatureConfig
+}
+
+// Overflow policies applied when a target queue is full.
+const (
+	OverflowReject     = "reject"
+	OverflowDropOldest = "drop_oldest"
+	OverflowCoalesce   = "coalesce"
+)
+
+// RateLimit is a token bucket; Rate is in messages per second and zero means
+// unlimited.
+type RateLimit struct {
+	Rate  float64
+	Burst int
+}
+
+// ChannelLimits layers a limiter shared by every target of a channel (one bot
+// or app) over the limiter of each target.
+type ChannelLimits struct {
+	Global RateLimit
+	Target RateLimit
+}
+
+// defaultChannelLimits follow the documented platform quotas.
+var defaultChannelLimits = map[string]ChannelLimits{
+	// 1000 messages per minute per app, 5 QPS per chat shared by all bots.
+	"feishu": {
+		Global: RateLimit{Rate: 1000.0 / 60, Burst: 50},
+		Target: RateLimit{Rate: 5, Burst: 5},
+	},
+	// About 30 messages per second per bot, 20 messages per minute per group.
+	"telegram": {
+		Global: RateLimit{Rate: 30, Burst: 30},
+		Target: RateLimit{Rate: 20.0 / 60, Burst: 3},
+	},
+	// One message per second per channel with short bursts, for both
+	// chat.postMessage and incoming webhooks.
+	"slack": {
+		Target: RateLimit{Rate: 1, Burst: 3},
+	},
+	// 50 requests per second per bot; about 5 messages per 5 seconds per
+	// channel or webhook.
+	"discord": {
+		Global: RateLimit{Rate: 50, Burst: 50},
+		Target: RateLimit{Rate: 1, Burst: 5},
+	},
+	// 20 messages per minute per robot, and a robot that exceeds it is
+	// blocked for 10 minutes. Each target is one robot; 19/min with no burst
+	// keeps any 60s window at or below 20.
+	"dingtalk": {
+		Target: RateLimit{Rate: 19.0 / 60, Burst: 1},
+	},
+	// Group robots allow 20 messages per minute and app messages 30 per
+	// minute to the same member.
+	"wecom": {
+		Target: RateLimit{Rate: 19.0 / 60, Burst: 1},
+	},
+	// Teams throttles webhooks that post more than 4 requests per second.
+	"teams": {
+		Target: RateLimit{Rate: 1, Burst: 4},
+	},
+	// ntfy.sh allows a burst of 60 messages per visitor, refilled at one
+	// every 5 seconds.
+	"ntfy": {
+		Global: RateLimit{Rate: 0.2, Burst: 60},
+	},
+	// Gotify is self-hosted and has no limits of its own.
+	"gotify": {
+		Target: RateLimit{Rate: 5, Burst: 10},
+	},
+	"bark": {
+		Target: RateLimit{Rate: 1, Burst: 5},
+	},
+	// Pushover asks clients not to send more than two requests at once.
+	"pushover": {
+		Global: RateLimit{Rate: 2, Burst: 2},
+	},
+	// Synapse's default message limit per user is 0.2/s with bursts of 10.
+	"matrix": {
+		Global: RateLimit{Rate: 0.2, Burst: 10},
+	},
+	// Outbound webhooks go to our own systems; the limits only smooth bursts.
+	"webhook": {
+		Target: RateLimit{Rate: 5, Burst: 10},
+	},
+	// SMTP relays rarely publish limits; stay well below typical ones.
+	"email": {
+		Global: RateLimit{Rate: 5, Burst: 10},
+		Target: RateLimit{Rate: 1, Burst: 5},
+	},
+}
+
 type QueueConfig struct {
 	RatePerSecond float64
 	MaxAttempts   int
 	RetryDelay    time.Duration
-	BufferSize    int
-	IdleTimeout   time.Duration
+	// MaxRateLimited is how many times a rate-limited task is rescheduled
+	// before it is dead-lettered; zero retries without limit.
+	MaxRateLimited  int
+	BufferSize      int
+	IdleTimeout     time.Duration
+	DataDir         string
+	DeadLetterMax   int
+	StatusRetention time.Duration
+	OverflowPolicy  string
+	// CoalesceTitle and CoalesceMessage make up the summary sent by the
+	// coalesce policy; "{count}" in the message is replaced by the number of
+	// coalesced tasks.
+	CoalesceTitle   string
+	CoalesceMessage string
+	DedupWindow     time.Duration
+	// BreakerThreshold consecutive failures open a circuit; zero disables breakers.
+	BreakerThreshold int
+	BreakerCooldown  time.Duration
+	Limits           map[string]ChannelLimits
+}
+
+// LimitsFor returns the rate limits of a channel. Channels without platform
+// defaults are limited per target by QUEUE_RATE_LIMIT only.
+func (c QueueConfig) LimitsFor(channel string) ChannelLimits {
+	if limits, ok := c.Limits[channel]; ok {
+		return limits
+	}
+	return ChannelLimits{Target: RateLimit{Rate: c.RatePerSecond, Burst: 1}}
 }
 
 func Load() (*Config, error) {
@@ -51,33 +385,266 @@ func Load() (*Config, error) {
 		Telegram: TelegramConfig{
 			BotToken: getEnv("APP_TELEGRAM_BOT_TOKEN", ""),
 		},
+		Slack: SlackConfig{
+			BotToken: getEnv("APP_SLACK_BOT_TOKEN", ""),
+			APIURL:   getEnv("APP_SLACK_API_URL", "https://slack.com/api"),
+		},
+		Discord: DiscordConfig{
+			BotToken: getEnv("APP_DISCORD_BOT_TOKEN", ""),
+			APIURL:   getEnv("APP_DISCORD_API_URL", "https://discord.com/api/v10"),
+		},
+		DingTalk: DingTalkConfig{
+			APIURL: getEnv("APP_DINGTALK_API_URL", "https://oapi.dingtalk.com"),
+		},
+		WeCom: WeComConfig{
+			CorpID:     getEnv("APP_WECOM_CORP_ID", ""),
+			CorpSecret: getEnv("APP_WECOM_CORP_SECRET", ""),
+			AgentID:    getEnvInt("APP_WECOM_AGENT_ID", 0),
+			APIURL:     getEnv("APP_WECOM_API_URL", "https://qyapi.weixin.qq.com"),
+		},
+		Email: EmailConfig{
+			Host:     getEnv("APP_SMTP_HOST", ""),
+			Port:     getEnvInt("APP_SMTP_PORT", 587),
+			Username: getEnv("APP_SMTP_USERNAME", ""),
+			Password: getEnv("APP_SMTP_PASSWORD", ""),
+			From:     getEnv("APP_SMTP_FROM", ""),
+			StartTLS: getEnvBool("APP_SMTP_STARTTLS", true),
+		},
+		Ntfy: NtfyConfig{
+			ServerURL: getEnv("APP_NTFY_URL", ""),
+			Token:     getEnv("APP_NTFY_TOKEN", ""),
+		},
+		Gotify: GotifyConfig{
+			ServerURL: getEnv("APP_GOTIFY_URL", ""),
+		},
+		Bark: BarkConfig{
+			ServerURL: getEnv("APP_BARK_URL", "https://api.day.app"),
+		},
+		Pushover: PushoverConfig{
+			AppToken: getEnv("APP_PUSHOVER_TOKEN", ""),
+			APIURL:   getEnv("APP_PUSHOVER_API_URL", "https://api.pushover.net"),
+		},
+		Matrix: MatrixConfig{
+			HomeserverURL: getEnv("APP_MATRIX_HOMESERVER_URL", "https://matrix-client.matrix.org"),
+			AccessToken:   getEnv("APP_MATRIX_ACCESS_TOKEN", ""),
+		},
+		Webhooks: WebhooksConfig{
+			Grafana: SignatureConfig{
+				Secret:          getEnv("APP_GRAFANA_HMAC_SECRET", ""),
+				Header:          getEnv("APP_GRAFANA_HMAC_HEADER", "X-Grafana-Alerting-Signature"),
+				TimestampHeader: getEnv("APP_GRAFANA_HMAC_TIMESTAMP_HEADER", "X-Grafana-Alerting-Timestamp"),
+				Tolerance:       getEnvDuration("APP_WEBHOOK_HMAC_TOLERANCE", 5*time.Minute),
+			},
+		},
 		Queue: QueueConfig{
-			RatePerSecond: getEnvFloat("QUEUE_RATE_LIMIT", 1.0),
-			MaxAttempts:   getEnvInt("QUEUE_MAX_ATTEMPTS", 3),
-			RetryDelay:    getEnvDuration("QUEUE_RETRY_DELAY", time.Second),
-			BufferSize:    getEnvInt("QUEUE_BUFFER_SIZE", 1000),
-			IdleTimeout:   getEnvDuration("QUEUE_IDLE_TIMEOUT", 5*time.Minute),
+			RatePerSecond:    getEnvFloat("QUEUE_RATE_LIMIT", 1.0),
+			MaxAttempts:      getEnvInt("QUEUE_MAX_ATTEMPTS", 3),
+			RetryDelay:       getEnvDuration("QUEUE_RETRY_DELAY", time.Second),
+			MaxRateLimited:   getEnvInt("QUEUE_MAX_RATE_LIMITED", 10),
+			BufferSize:       getEnvInt("QUEUE_BUFFER_SIZE", 1000),
+			IdleTimeout:      getEnvDuration("QUEUE_IDLE_TIMEOUT", 5*time.Minute),
+			DataDir:          getEnv("QUEUE_DATA_DIR", ""),
+			DeadLetterMax:    getEnvInt("QUEUE_DEAD_LETTER_MAX", 1000),
+			StatusRetention:  getEnvDuration("QUEUE_STATUS_RETENTION", time.Hour),
+			OverflowPolicy:   getEnv("QUEUE_OVERFLOW_POLICY", OverflowReject),
+			CoalesceTitle:    getEnv("QUEUE_COALESCE_TITLE", "部分消息未送达"),
+			CoalesceMessage:  getEnv("QUEUE_COALESCE_MESSAGE", "由于队列已满，有 {count} 条发往此会话的消息未能送达。"),
+			DedupWindow:      getEnvDuration("QUEUE_DEDUP_WINDOW", time.Hour),
+			BreakerThreshold: getEnvInt("QUEUE_BREAKER_THRESHOLD", 5),
+			BreakerCooldown:  getEnvDuration("QUEUE_BREAKER_COOLDOWN", time.Minute),
 		},
 	}
+	cfg.Queue.Limits = loadChannelLimits()
+	if err := getEnvJSON("APP_SLACK_WEBHOOKS", &cfg.Slack.Webhooks); err != nil {
+		return nil, err
+	}
+	if err := getEnvJSON("APP_DISCORD_WEBHOOKS", &cfg.Discord.Webhooks); err != nil {
+		return nil, err
+	}
+	if err := getEnvJSON("APP_DINGTALK_ROBOTS", &cfg.DingTalk.Robots); err != nil {
+		return nil, err
+	}
+	if err := getEnvJSON("APP_WECOM_ROBOTS", &cfg.WeCom.Robots); err != nil {
+		return nil, err
+	}
+	if err := getEnvJSON("APP_TEAMS_WEBHOOKS", &cfg.Teams.Webhooks); err != nil {
+		return nil, err
+	}
+	if err := getEnvJSON("APP_GOTIFY_APPS", &cfg.Gotify.Apps); err != nil {
+		return nil, err
+	}
+	if err := getEnvJSON("APP_BARK_DEVICES", &cfg.Bark.Devices); err != nil {
+		return nil, err
+	}
+	if err := getEnvJSON("APP_WEBHOOK_ENDPOINTS", &cfg.Outbound.Endpoints); err != nil {
+		return nil, err
+	}
+	for name, endpoint := range cfg.Outbound.Endpoints {
+		if endpoint.Method == "" {
+			endpoint.Method = http.MethodPost
+		}
+		if endpoint.SignatureHeader == "" {
+			endpoint.SignatureHeader = "X-Notify-Signature"
+		}
+		if endpoint.TimestampHeader == "" {
+			endpoint.TimestampHeader = "X-Notify-Timestamp"
+		}
+		cfg.Outbound.Endpoints[name] = endpoint
+	}
+	cfg.Log.Bodies = getEnvBool("APP_LOG_BODIES", false)
+	if err := getEnvJSON("APP_LOG_REDACT_PATTERNS", &cfg.Log.RedactPatterns); err != nil {
+		return nil, err
+	}
+	if err := getEnvJSON("APP_SMTP_ALLOWED_RECIPIENTS", &cfg.Email.AllowedRecipients); err != nil {
+		return nil, err
+	}
+	if err := getEnvJSON("APP_API_KEYS", &cfg.Auth.APIKeys); err != nil {
+		return nil, err
+	}
 	if err := cfg.validate(); err != nil {
 		return nil, err
 	}
 	return cfg, nil
 }
 
+// legacyRateLimitChannels existed when QUEUE_RATE_LIMIT was the only limit.
+var legacyRateLimitChannels = []string{"feishu", "telegram"}
+
+// loadChannelLimits applies QUEUE_<CHANNEL>_{GLOBAL,TARGET}_{RATE,BURST}
+// overrides to the platform defaults. An explicit QUEUE_RATE_LIMIT replaces
+// the default per-target rate of the legacy channels, as it did before
+// per-channel limits existed.
+func loadChannelLimits() map[string]ChannelLimits {
+	limits := make(map[string]ChannelLimits, len(defaultChannelLimits))
+	for channel, l := range defaultChannelLimits {
+		if os.Getenv("QUEUE_RATE_LIMIT") != "" && slices.Contains(legacyRateLimitChannels, channel) {
+			l.Target = RateLimit{Rate: getEnvFloat("QUEUE_RATE_LIMIT", l.Target.Rate), Burst: 1}
+		}
+
+		prefix := "QUEUE_" + strings.ToUpper(channel) + "_"
+		l.Global.Rate = getEnvFloat(prefix+"GLOBAL_RATE", l.Global.Rate)
+		l.Global.Burst = getEnvInt(prefix+"GLOBAL_BURST", l.Global.Burst)
+		l.Target.Rate = getEnvFloat(prefix+"TARGET_RATE", l.Target.Rate)
+		l.Target.Burst = getEnvInt(prefix+"TARGET_BURST", l.Target.Burst)
+		limits[channel] = l
+	}
+	return limits
+}
+
 func (c *Config) validate() error {
 	feishuPartial := (c.Feishu.AppID == "") != (c.Feishu.AppSecret == "")
 	if feishuPartial {
 		return fmt.Errorf("feishu: APP_FEISHU_ID and APP_FEISHU_SECRET must both be set")
 	}
 
-	if c.Feishu.AppID == "" && c.Telegram.BotToken == "" {
-		return fmt.Errorf("at least one service must be configured (feishu or telegram)")
+	wecomPartial := c.WeCom.CorpID != "" && (c.WeCom.CorpSecret == "" || c.WeCom.AgentID == 0)
+	if wecomPartial {
+		return fmt.Errorf("wecom: APP_WECOM_CORP_ID, APP_WECOM_CORP_SECRET and APP_WECOM_AGENT_ID must all be set")
+	}
+
+	if c.Feishu.AppID == "" && c.Telegram.BotToken == "" && !c.Slack.Enabled() && !c.Discord.Enabled() &&
+		len(c.DingTalk.Robots) == 0 && !c.WeCom.Enabled() && !c.Email.Enabled() && !c.Teams.Enabled() &&
+		len(c.Outbound.Endpoints) == 0 && c.Ntfy.ServerURL == "" && len(c.Gotify.Apps) == 0 && c.Bark.Devices == nil &&
+		c.Pushover.AppToken == "" && c.Matrix.AccessToken == "" {
+		return fmt.Errorf("at least one service must be configured " +
+			"(feishu, telegram, slack, discord, dingtalk, wecom, email, teams, webhook, ntfy, gotify, bark, pushover or matrix)")
+	}
+	if len(c.Gotify.Apps) > 0 && c.Gotify.ServerURL == "" {
+		return fmt.Errorf("gotify: APP_GOTIFY_URL must be set with APP_GOTIFY_APPS")
+	}
+	if c.Email.Enabled() && c.Email.From == "" {
+		return fmt.Errorf("email: APP_SMTP_FROM must be set with APP_SMTP_HOST")
+	}
+	// Without API keys anyone who can reach the service could use it to send
+	// mail to arbitrary addresses.
+	if c.Email.Enabled() && len(c.Auth.APIKeys) == 0 && len(c.Email.AllowedRecipients) == 0 {
+		return fmt.Errorf("email: set APP_API_KEYS or APP_SMTP_ALLOWED_RECIPIENTS to enable email")
+	}
+	for name, robot := range c.DingTalk.Robots {
+		if robot.AccessToken == "" {
+			return fmt.Errorf("dingtalk: robot %q needs an accessToken", name)
+		}
+	}
+
+	for name, endpoint := range c.Outbound.Endpoints {
+		u, err := url.Parse(endpoint.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("webhook: endpoint %q needs an http(s) url", name)
+		}
+		switch endpoint.Method {
+		case http.MethodPost, http.MethodPut, http.MethodPatch:
+		default:
+			return fmt.Errorf("webhook: endpoint %q has unsupported method %q", name, endpoint.Method)
+		}
+	}
+
+	switch c.Queue.OverflowPolicy {
+	case OverflowReject, OverflowDropOldest, OverflowCoalesce:
+	default:
+		return fmt.Errorf("queue: invalid QUEUE_OVERFLOW_POLICY %q", c.Queue.OverflowPolicy)
+	}
+
+	names := make(map[string]bool)
+	for i, key := range c.Auth.APIKeys {
+		if key.Name == "" || key.Key == "" {
+			return fmt.Errorf("auth: APP_API_KEYS[%d] needs a name and a key", i)
+		}
+		if names[key.Name] {
+			return fmt.Errorf("auth: duplicate API key name %q", key.Name)
+		}
+		names[key.Name] = true
+		for _, scope := range key.Scopes {
+			switch scope {
+			case ScopeSend, ScopeRaw, ScopeChats, ScopeAdmin:
+			default:
+				return fmt.Errorf("auth: API key %q has unknown scope %q", key.Name, scope)
+			}
+		}
 	}
 
 	return nil
 }
 
+// Secrets returns the configured credentials, which must never be logged.
+func (c *Config) Secrets() []string {
+	secrets := []string{c.Feishu.AppSecret, c.Telegram.BotToken, c.Slack.BotToken, c.Discord.BotToken, c.Webhooks.Grafana.Secret}
+	for _, url := range c.Slack.Webhooks {
+		secrets = append(secrets, url)
+	}
+	for _, url := range c.Discord.Webhooks {
+		secrets = append(secrets, url)
+	}
+	for _, robot := range c.DingTalk.Robots {
+		secrets = append(secrets, robot.AccessToken, robot.Secret)
+	}
+	secrets = append(secrets, c.WeCom.CorpSecret, c.Email.Password)
+	for _, key := range c.WeCom.Robots {
+		secrets = append(secrets, key)
+	}
+	secrets = append(secrets, c.Ntfy.Token, c.Pushover.AppToken, c.Matrix.AccessToken)
+	for _, token := range c.Gotify.Apps {
+		secrets = append(secrets, token)
+	}
+	for _, key := range c.Bark.Devices {
+		secrets = append(secrets, key)
+	}
+	for _, url := range c.Teams.Webhooks {
+		secrets = append(secrets, url)
+	}
+	for _, endpoint := range c.Outbound.Endpoints {
+		secrets = append(secrets, endpoint.URL, endpoint.Secret)
+		for name, value := range endpoint.Headers {
+			if isCredentialHeader(name) {
+				secrets = append(secrets, value)
+			}
+		}
+	}
+	for _, key := range c.Auth.APIKeys {
+		secrets = append(secrets, key.Key)
+	}
+	return secrets
+}
+
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
@@ -94,6 +661,15 @@ func getEnvInt(key string, defaultValue int) int {
 	return defaultValue
 }
 
+func getEnvBool(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if boolVal, err := strconv.ParseBool(value); err == nil {
+			return boolVal
+		}
+	}
+	return defaultValue
+}
+
 func getEnvFloat(key string, defaultValue float64) float64 {
 	if value := os.Getenv(key); value != "" {
 		if floatVal, err := strconv.ParseFloat(value, 64); err == nil {
@@ -111,3 +687,27 @@ func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
 	}
 	return defaultValue
 }
+
+// getEnvJSON decodes a JSON value from the environment into v, leaving v
+// untouched when the variable is unset.
+func getEnvJSON(key string, v any) error {
+	value := os.Getenv(key)
+	if value == "" {
+		return nil
+	}
+	if err := json.Unmarshal([]byte(value), v); err != nil {
+		return fmt.Errorf("%s: invalid JSON: %w", key, err)
+	}
+	return nil
+}
+
+// isCredentialHeader reports whether a header usually carries a credential.
+func isCredentialHeader(name string) bool {
+	name = strings.ToLower(name)
+	for _, word := range []string{"authorization", "token", "key", "secret", "signature"} {
+		if strings.Contains(name, word) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,151 @@
+package handler
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"notify/internal/config"
+	"notify/internal/queue"
+)
+
+type apiKeyContextKey struct{}
+
+type apiKey struct {
+	config.APIKey
+	hash [sha256.Size]byte
+}
+
+var apiKeys []apiKey
+
+// InitAuth installs the accepted API keys. With no keys every request is
+// allowed, as before authentication existed.
+func InitAuth(cfg config.AuthConfig) {
+	apiKeys = make([]apiKey, 0, len(cfg.APIKeys))
+	for _, key := range cfg.APIKeys {
+		apiKeys = append(apiKeys, apiKey{APIKey: key, hash: sha256.Sum256([]byte(key.Key))})
+	}
+	if len(apiKeys) == 0 {
+		slog.Warn("No API keys configured, authentication is disabled")
+	}
+}
+
+// RequireScope rejects requests without a valid API key holding scope.
+// The key is read from "Authorization: Bearer", X-API-Key or the basic auth
+// password.
+func RequireScope(scope string, next http.HandlerFunc) http.HandlerFunc {
+	return requireScope(scope, false, next)
+}
+
+// RequireWebhookScope is RequireScope that also accepts a token query
+// parameter, since Grafana contact points cannot set custom headers.
+func RequireWebhookScope(scope string, next http.HandlerFunc) http.HandlerFunc {
+	return requireScope(scope, true, next)
+}
+
+func requireScope(scope string, allowQuery bool, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if len(apiKeys) == 0 {
+			next(w, r)
+			return
+		}
+
+		key := lookupAPIKey(requestAPIKey(r, allowQuery))
+		if key == nil {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="notify"`)
+			writeError(w, http.StatusUnauthorized, "UNAUTHORIZED", "missing or invalid API key")
+			return
+		}
+		if !key.HasScope(scope) {
+			writeError(w, http.StatusForbidden, "FORBIDDEN", "API key lacks the "+scope+" scope")
+			return
+		}
+
+		next(w, r.WithContext(context.WithValue(r.Context(), apiKeyContextKey{}, key)))
+	}
+}
+
+func requestAPIKey(r *http.Request, allowQuery bool) string {
+	if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		return strings.TrimSpace(token)
+	}
+	if key := r.Header.Get("X-API-Key"); key != "" {
+		return key
+	}
+	if _, password, ok := r.BasicAuth(); ok {
+		return password
+	}
+	if allowQuery {
+		return r.URL.Query().Get("token")
+	}
+	return ""
+}
+
+// lookupAPIKey compares against every key in constant time.
+func lookupAPIKey(presented string) *config.APIKey {
+	if presented == "" {
+		return nil
+	}
+
+	hash := sha256.Sum256([]byte(presented))
+	var found *config.APIKey
+	for i := range apiKeys {
+		if subtle.ConstantTimeCompare(hash[:], apiKeys[i].hash[:]) == 1 {
+			found = &apiKeys[i].APIKey
+		}
+	}
+	return found
+}
+
+// requestKey returns the API key that authenticated r, or nil when
+// authentication is disabled.
+func requestKey(r *http.Request) *config.APIKey {
+	key, _ := r.Context().Value(apiKeyContextKey{}).(*config.APIKey)
+	return key
+}
+
+// authorizeTarget checks the request's API key may address target on
+// channel. Writes a 403 response and returns false if it may not.
+func authorizeTarget(w http.ResponseWriter, r *http.Request, channel, target string) bool {
+	if key := requestKey(r); key != nil && !key.Allows(channel, target) {
+		writeError(w, http.StatusForbidden, "FORBIDDEN", "API key may not send to "+channel+":"+target)
+		return false
+	}
+	return true
+}
+
+// authorizeChannel is authorizeTarget for requests that are not about a
+// single target.
+func authorizeChannel(w http.ResponseWriter, r *http.Request, channel string) bool {
+	if key := requestKey(r); key != nil && !key.AllowsChannel(channel) {
+		writeError(w, http.StatusForbidden, "FORBIDDEN", "API key may not use channel "+channel)
+		return false
+	}
+	return true
+}
+
+// keyAllows reports whether the request's API key may see target on channel.
+// Admin endpoints use it to leave out tasks and breakers outside the key's
+// scope.
+func keyAllows(r *http.Request, channel, target string) bool {
+	key := requestKey(r)
+	return key == nil || key.Allows(channel, target)
+}
+
+// authorizeTask checks the request's API key may act on the task with id.
+// Tasks outside the key's scope are reported as not found rather than
+// confirmed to exist. Writes a 404 response and returns false if it may not.
+func authorizeTask(w http.ResponseWriter, r *http.Request, id string) bool {
+	if requestKey(r) == nil {
+		return true
+	}
+	status, err := queue.GetManager().TaskStatus(id)
+	if err != nil || !keyAllows(r, status.Channel, status.Target) {
+		writeError(w, http.StatusNotFound, "NOT_FOUND", queue.ErrTaskNotFound.Error())
+		return false
+	}
+	return true
+}
@@ -0,0 +1,117 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"notify/internal/config"
+	"notify/internal/queue"
+	"notify/internal/service"
+)
+
+func TestRequireScope(t *testing.T) {
+	InitAuth(config.AuthConfig{APIKeys: []config.APIKey{
+		{Name: "ci", Key: "ci-secret", Scopes: []string{config.ScopeSend}, Channels: []string{"telegram"}, Targets: []string{"-100"}},
+		{Name: "ops", Key: "ops-secret", Scopes: []string{config.ScopeSend, config.ScopeAdmin}},
+	}})
+	t.Cleanup(func() { InitAuth(config.AuthConfig{}) })
+
+	send := func(w http.ResponseWriter, r *http.Request) {
+		if !authorizeTarget(w, r, "telegram", r.URL.Query().Get("target")) {
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+
+	tests := []struct {
+		name    string
+		scope   string
+		webhook bool
+		url     string
+		header  [2]string
+		basic   string
+		want    int
+	}{
+		{name: "missing key", scope: config.ScopeSend, url: "/?target=-100", want: http.StatusUnauthorized},
+		{name: "wrong key", scope: config.ScopeSend, url: "/?target=-100", header: [2]string{"X-API-Key", "nope"}, want: http.StatusUnauthorized},
+		{name: "bearer", scope: config.ScopeSend, url: "/?target=-100", header: [2]string{"Authorization", "Bearer ci-secret"}, want: http.StatusNoContent},
+		{name: "x-api-key", scope: config.ScopeSend, url: "/?target=-100", header: [2]string{"X-API-Key", "ci-secret"}, want: http.StatusNoContent},
+		{name: "missing scope", scope: config.ScopeAdmin, url: "/?target=-100", header: [2]string{"X-API-Key", "ci-secret"}, want: http.StatusForbidden},
+		{name: "target outside scope", scope: config.ScopeSend, url: "/?target=-200", header: [2]string{"X-API-Key", "ci-secret"}, want: http.StatusForbidden},
+		{name: "unrestricted key", scope: config.ScopeSend, url: "/?target=-200", header: [2]string{"X-API-Key", "ops-secret"}, want: http.StatusNoContent},
+		{name: "query token on api", scope: config.ScopeSend, url: "/?target=-100&token=ci-secret", want: http.StatusUnauthorized},
+		{name: "query token on webhook", scope: config.ScopeSend, webhook: true, url: "/?target=-100&token=ci-secret", want: http.StatusNoContent},
+		{name: "basic auth on webhook", scope: config.ScopeSend, webhook: true, url: "/?target=-100", basic: "ci-secret", want: http.StatusNoContent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := RequireScope(tt.scope, send)
+			if tt.webhook {
+				h = RequireWebhookScope(tt.scope, send)
+			}
+
+			r := httptest.NewRequest(http.MethodPost, tt.url, nil)
+			if tt.header[0] != "" {
+				r.Header.Set(tt.header[0], tt.header[1])
+			}
+			if tt.basic != "" {
+				r.SetBasicAuth("grafana", tt.basic)
+			}
+			w := httptest.NewRecorder()
+			h(w, r)
+
+			if w.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.want, w.Body)
+			}
+		})
+	}
+}
+
+func TestAdminEndpointsRespectKeyScope(t *testing.T) {
+	m := initQueue(t)
+	InitAuth(config.AuthConfig{APIKeys: []config.APIKey{
+		{Name: "ci", Key: "ci-secret", Scopes: []string{config.ScopeAdmin}, Channels: []string{"telegram"}, Targets: []string{"-100"}},
+	}})
+	t.Cleanup(func() { InitAuth(config.AuthConfig{}) })
+
+	ids := make(map[string]string)
+	for _, target := range []string{"-100", "-200"} {
+		id, err := m.Enqueue(service.ChannelTelegram, target, map[string]any{"text": "hi"},
+			queue.EnqueueOptions{SendAt: time.Now().Add(time.Hour)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids[target] = id
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/scheduled", RequireScope(config.ScopeAdmin, ListScheduled))
+	mux.HandleFunc("DELETE /api/scheduled/{id}", RequireScope(config.ScopeAdmin, CancelScheduled))
+	do := func(method, path string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, path, nil)
+		r.Header.Set("X-API-Key", "ci-secret")
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, r)
+		return w
+	}
+
+	var tasks []queue.Task
+	_ = json.Unmarshal(do(http.MethodGet, "/api/scheduled").Body.Bytes(), &tasks)
+	if len(tasks) != 1 || tasks[0].Target != "-100" {
+		t.Fatalf("scheduled = %#v, want only -100", tasks)
+	}
+
+	if w := do(http.MethodDelete, "/api/scheduled/"+ids["-200"]); w.Code != http.StatusNotFound {
+		t.Fatalf("cancel outside scope = %d %s", w.Code, w.Body)
+	}
+	if len(m.Scheduled()) != 2 {
+		t.Fatal("task outside scope was cancelled")
+	}
+	if w := do(http.MethodDelete, "/api/scheduled/"+ids["-100"]); w.Code != http.StatusOK {
+		t.Fatalf("cancel within scope = %d %s", w.Code, w.Body)
+	}
+}
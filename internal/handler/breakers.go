@@ -0,0 +1,28 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+
+	"notify/internal/queue"
+)
+
+// ListBreakers lists the breakers within the API key's scope. Breakers are
+// named "channel" or "channel:target".
+func ListBreakers(w http.ResponseWriter, r *http.Request) {
+	breakers := queue.GetManager().Breakers()
+	key := requestKey(r)
+	visible := make([]queue.BreakerStatus, 0, len(breakers))
+	for _, b := range breakers {
+		channel, target, perTarget := strings.Cut(b.Name, ":")
+		switch {
+		case key == nil:
+		case perTarget && !key.Allows(channel, target):
+			continue
+		case !perTarget && !key.AllowsChannel(channel):
+			continue
+		}
+		visible = append(visible, b)
+	}
+	writeJSON(w, http.StatusOK, visible)
+}
@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"notify/internal/queue"
+	"notify/internal/service"
+)
+
+func ListDeadLetters(w http.ResponseWriter, r *http.Request) {
+	tasks, err := queue.GetManager().DeadLetters()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "QUEUE_ERROR", err.Error())
+		return
+	}
+
+	visible := make([]*queue.Task, 0, len(tasks))
+	for _, task := range tasks {
+		if keyAllows(r, string(task.Channel), task.Target) {
+			visible = append(visible, task)
+		}
+	}
+	writeJSON(w, http.StatusOK, visible)
+}
+
+func ReplayDeadLetter(w http.ResponseWriter, r *http.Request) {
+	if !authorizeTask(w, r, r.PathValue("id")) {
+		return
+	}
+
+	task, err := queue.GetManager().ReplayDeadLetter(r.PathValue("id"))
+	if err != nil {
+		writeDeadLetterError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, task)
+}
+
+func DeleteDeadLetter(w http.ResponseWriter, r *http.Request) {
+	if !authorizeTask(w, r, r.PathValue("id")) {
+		return
+	}
+
+	if err := queue.GetManager().PurgeDeadLetter(r.PathValue("id")); err != nil {
+		writeDeadLetterError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, &service.SendResult{Success: true})
+}
+
+func writeDeadLetterError(w http.ResponseWriter, err error) {
+	if errors.Is(err, queue.ErrTaskNotFound) {
+		writeError(w, http.StatusNotFound, "NOT_FOUND", err.Error())
+		return
+	}
+	writeEnqueueError(w, err)
+}
@@ -0,0 +1,113 @@
+package handler
+
+import (
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"notify/internal/queue"
+	"notify/internal/service"
+)
+
+// healthCheckTTL keeps frequent readiness probes from calling the platforms
+// on every request.
+const healthCheckTTL = 30 * time.Second
+
+// ServiceHealth is all /readyz reveals about a channel; it is served without
+// authentication, so failure details are only logged.
+type ServiceHealth struct {
+	OK bool `json:"ok"`
+}
+
+type ReadinessResponse struct {
+	Status   string                   `json:"status"`
+	Draining bool                     `json:"draining"`
+	Services map[string]ServiceHealth `json:"services"`
+	Queue    queue.QueueStats         `json:"queue"`
+}
+
+type healthResult struct {
+	health    ServiceHealth
+	checkedAt time.Time
+}
+
+var (
+	healthMu    sync.Mutex
+	healthCache = make(map[service.Channel]healthResult)
+)
+
+func Healthz(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// Readyz reports ready when every configured service can authenticate and the
+// queue is not draining for shutdown. Saturated queues are reported but do not
+// fail readiness, since one busy chat should not take the instance out of
+// rotation.
+func Readyz(w http.ResponseWriter, r *http.Request) {
+	m := queue.GetManager()
+	resp := ReadinessResponse{
+		Status:   "ready",
+		Draining: m.Draining(),
+		Services: checkServices(),
+		Queue:    m.Stats(),
+	}
+
+	ready := !resp.Draining
+	for _, health := range resp.Services {
+		ready = ready && health.OK
+	}
+	if !ready {
+		resp.Status = "not_ready"
+		writeJSON(w, http.StatusServiceUnavailable, resp)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// checkServices runs the credential checks concurrently, reusing results
+// younger than healthCheckTTL.
+func checkServices() map[string]ServiceHealth {
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		results = make(map[string]ServiceHealth)
+	)
+	for _, svc := range service.Services() {
+		checker, ok := svc.(service.HealthChecker)
+		if !ok {
+			results[string(svc.Channel())] = ServiceHealth{OK: true}
+			continue
+		}
+
+		wg.Go(func() {
+			health := checkService(svc.Channel(), checker)
+			mu.Lock()
+			results[string(svc.Channel())] = health
+			mu.Unlock()
+		})
+	}
+	wg.Wait()
+	return results
+}
+
+func checkService(channel service.Channel, checker service.HealthChecker) ServiceHealth {
+	healthMu.Lock()
+	cached, ok := healthCache[channel]
+	healthMu.Unlock()
+	if ok && time.Since(cached.checkedAt) < healthCheckTTL {
+		return cached.health
+	}
+
+	health := ServiceHealth{OK: true}
+	if err := checker.CheckHealth(); err != nil {
+		slog.Warn("Health check failed", "channel", channel, "error", err)
+		health = ServiceHealth{}
+	}
+
+	healthMu.Lock()
+	healthCache[channel] = healthResult{health: health, checkedAt: time.Now()}
+	healthMu.Unlock()
+	return health
+}
@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"notify/internal/config"
+	"notify/internal/queue"
+	"notify/internal/service"
+)
+
+func TestReadyzHidesFailureDetails(t *testing.T) {
+	initQueue(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+	if err := service.Init(&config.Config{Ntfy: config.NtfyConfig{ServerURL: srv.URL}}); err != nil {
+		t.Fatal(err)
+	}
+	healthCache = make(map[service.Channel]healthResult)
+
+	w := httptest.NewRecorder()
+	Readyz(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	body := w.Body.String()
+	if w.Code != http.StatusServiceUnavailable || !strings.Contains(body, `"ntfy":{"ok":false}`) {
+		t.Fatalf("readyz = %d %s", w.Code, body)
+	}
+	if strings.Contains(body, "401") {
+		t.Fatalf("readyz leaks details: %s", body)
+	}
+}
+
+func TestReadyzReportsQueueSaturation(t *testing.T) {
+	received := make(chan struct{}, 1)
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/health" {
+			_, _ = w.Write([]byte(`{"healthy":true}`))
+			return
+		}
+		select {
+		case received <- struct{}{}:
+		default:
+		}
+		<-release
+		_, _ = w.Write([]byte(`{"id":"msg"}`))
+	}))
+	t.Cleanup(srv.Close)
+	if err := service.Init(&config.Config{Ntfy: config.NtfyConfig{ServerURL: srv.URL}}); err != nil {
+		t.Fatal(err)
+	}
+	healthCache = make(map[service.Channel]healthResult)
+	if err := queue.Init(config.QueueConfig{MaxAttempts: 1, BufferSize: 2, IdleTimeout: time.Minute, StatusRetention: time.Hour}); err != nil {
+		t.Fatal(err)
+	}
+	m := queue.GetManager()
+	t.Cleanup(m.Shutdown)
+	t.Cleanup(func() { close(release) })
+
+	// The worker holds the first message, so the next two fill the queue.
+	enqueue := func() {
+		if _, err := m.Enqueue(service.ChannelNtfy, "alerts", map[string]any{"message": "hi"}, queue.EnqueueOptions{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	enqueue()
+	<-received
+	enqueue()
+	enqueue()
+
+	w := httptest.NewRecorder()
+	Readyz(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	var resp ReadinessResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	want := queue.QueueStats{Queues: 1, Depth: 2, Capacity: 2, Saturated: 1}
+	if w.Code != http.StatusOK || resp.Queue != want {
+		t.Fatalf("readyz = %d %s", w.Code, w.Body)
+	}
+	if strings.Contains(w.Body.String(), "alerts") {
+		t.Fatalf("readyz names targets: %s", w.Body)
+	}
+}
@@ -1,55 +1,111 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"log/slog"
 	"net/http"
+	"strconv"
+	"time"
 
+	"notify/internal/config"
 	"notify/internal/queue"
 	"notify/internal/service"
 )
 
 type SendMessageRequest struct {
-	Channel string                `json:"channel"`
-	Target  string                `json:"target"`
-	Params  service.MessageParams `json:"params"`
+	Channel  string                `json:"channel"`
+	Target   string                `json:"target"`
+	Params   service.MessageParams `json:"params"`
+	DedupKey string                `json:"dedupKey,omitempty"`
+	SendAt   time.Time             `json:"sendAt,omitzero"`
+	Delay    string                `json:"delay,omitempty"`
+	Priority string                `json:"priority,omitempty"`
 }
 
 type SendRawMessageRequest struct {
-	Channel string         `json:"channel"`
-	Target  string         `json:"target"`
-	Message map[string]any `json:"message"`
+	Channel  string         `json:"channel"`
+	Target   string         `json:"target"`
+	Message  map[string]any `json:"message"`
+	DedupKey string         `json:"dedupKey,omitempty"`
+	SendAt   time.Time      `json:"sendAt,omitzero"`
+	Delay    string         `json:"delay,omitempty"`
+	Priority string         `json:"priority,omitempty"`
+}
+
+type EnqueueResponse struct {
+	Success bool   `json:"success"`
+	TaskID  string `json:"taskId"`
+	// Coalesced is set when the queue was full and the message was folded
+	// into a summary instead of being queued on its own.
+	Coalesced bool   `json:"coalesced,omitempty"`
+	MessageID string `json:"messageId,omitempty"`
 }
 
 type ErrorResponse struct {
 	Error   string `json:"error"`
 	Message string `json:"message"`
+	TaskID  string `json:"taskId,omitempty"`
 }
 
+const (
+	defaultWaitTimeout = 30 * time.Second
+	maxWaitTimeout     = 2 * time.Minute
+)
+
 func SendMessage(w http.ResponseWriter, r *http.Request) {
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
 		writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", "Failed to read request body")
 		return
 	}
-	slog.Info("Send message request received body=" + string(body))
+	logRequestBody(r, body)
+
+	waitTimeout, err := parseWaitTimeout(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", err.Error())
+		return
+	}
 
 	var req SendMessageRequest
 	if err := json.Unmarshal(body, &req); err != nil {
 		writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid request body")
 		return
 	}
+	slog.Info("Send message request received", "channel", req.Channel, "target", req.Target, "bytes", len(body))
 
-	channel, svc, ok := resolveService(w, req.Channel, req.Target)
+	channel, svc, ok := resolveService(w, r, req.Channel, req.Target)
 	if !ok {
 		return
 	}
 
+	sendAt, err := resolveSendAt(req.SendAt, req.Delay)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", err.Error())
+		return
+	}
+
+	priority, err := queue.ParsePriority(req.Priority)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", err.Error())
+		return
+	}
+
 	message := svc.BuildMessage(req.Params)
-	queue.GetManager().Enqueue(channel, req.Target, message)
+	taskID, err := queue.GetManager().Enqueue(channel, req.Target, message, queue.EnqueueOptions{
+		DedupKey: dedupKey(r, req.DedupKey),
+		SendAt:   sendAt,
+		Priority: priority,
+	})
+	if err != nil {
+		writeEnqueueError(w, err)
+		return
+	}
 
-	writeJSON(w, http.StatusOK, &service.SendResult{Success: true})
+	writeEnqueued(w, r, taskID, waitTimeout)
 }
 
 func SendRawMessage(w http.ResponseWriter, r *http.Request) {
@@ -58,22 +114,49 @@ func SendRawMessage(w http.ResponseWriter, r *http.Request) {
 		writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", "Failed to read request body")
 		return
 	}
-	slog.Info("Send raw message request received body=" + string(body))
+	logRequestBody(r, body)
+
+	waitTimeout, err := parseWaitTimeout(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", err.Error())
+		return
+	}
 
 	var req SendRawMessageRequest
 	if err := json.Unmarshal(body, &req); err != nil {
 		writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid request body")
 		return
 	}
+	slog.Info("Send raw message request received", "channel", req.Channel, "target", req.Target, "bytes", len(body))
 
-	channel, _, ok := resolveService(w, req.Channel, req.Target)
+	channel, _, ok := resolveService(w, r, req.Channel, req.Target)
 	if !ok {
 		return
 	}
 
-	queue.GetManager().Enqueue(channel, req.Target, req.Message)
+	sendAt, err := resolveSendAt(req.SendAt, req.Delay)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", err.Error())
+		return
+	}
 
-	writeJSON(w, http.StatusOK, &service.SendResult{Success: true})
+	priority, err := queue.ParsePriority(req.Priority)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", err.Error())
+		return
+	}
+
+	taskID, err := queue.GetManager().Enqueue(channel, req.Target, req.Message, queue.EnqueueOptions{
+		DedupKey: dedupKey(r, req.DedupKey),
+		SendAt:   sendAt,
+		Priority: priority,
+	})
+	if err != nil {
+		writeEnqueueError(w, err)
+		return
+	}
+
+	writeEnqueued(w, r, taskID, waitTimeout)
 }
 
 func ListChats(w http.ResponseWriter, r *http.Request) {
@@ -89,6 +172,10 @@ func ListChats(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !authorizeChannel(w, r, string(channel)) {
+		return
+	}
+
 	svc, err := service.GetService(channel)
 	if err != nil {
 		writeError(w, http.StatusNotFound, "NOT_FOUND", err.Error())
@@ -110,9 +197,120 @@ func ListChats(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, chats)
 }
 
-// resolveService validates channel/target and returns the corresponding service.
-// Writes an error response and returns false if validation fails.
-func resolveService(w http.ResponseWriter, channelStr, target string) (service.Channel, service.NotifyService, bool) {
+// logBodies enables debug logging of request bodies; see InitLogging.
+var logBodies bool
+
+// InitLogging applies the request logging settings.
+func InitLogging(cfg config.LogConfig) {
+	logBodies = cfg.Bodies
+}
+
+// logRequestBody writes the body at debug level when body logging is enabled.
+// The default logger redacts secrets before the record is written.
+func logRequestBody(r *http.Request, body []byte) {
+	if logBodies {
+		slog.Debug("Request body", "path", r.URL.Path, "body", string(body))
+	}
+}
+
+// parseWaitTimeout reads the opt-in ?wait=true&timeout=10s query parameters.
+// A zero duration means the caller does not wait for delivery.
+func parseWaitTimeout(r *http.Request) (time.Duration, error) {
+	query := r.URL.Query()
+	if query.Get("wait") != "true" {
+		return 0, nil
+	}
+
+	timeoutStr := query.Get("timeout")
+	if timeoutStr == "" {
+		return defaultWaitTimeout, nil
+	}
+	timeout, err := time.ParseDuration(timeoutStr)
+	if err != nil || timeout <= 0 || timeout > maxWaitTimeout {
+		return 0, fmt.Errorf("timeout must be a positive duration up to %s", maxWaitTimeout)
+	}
+	return timeout, nil
+}
+
+// resolveSendAt turns the optional sendAt/delay fields into a send time.
+// The zero time means send immediately.
+func resolveSendAt(sendAt time.Time, delay string) (time.Time, error) {
+	if delay == "" {
+		return sendAt, nil
+	}
+	if !sendAt.IsZero() {
+		return time.Time{}, fmt.Errorf("sendAt and delay cannot both be set")
+	}
+	d, err := time.ParseDuration(delay)
+	if err != nil || d < 0 {
+		return time.Time{}, fmt.Errorf("delay must be a non-negative duration such as 30m")
+	}
+	return time.Now().Add(d), nil
+}
+
+// dedupKey prefers the Idempotency-Key header over the dedupKey body field.
+func dedupKey(r *http.Request, bodyKey string) string {
+	if key := r.Header.Get("Idempotency-Key"); key != "" {
+		return key
+	}
+	return bodyKey
+}
+
+// writeEnqueued responds with the task ID, or with the delivery result when the
+// caller asked to wait for it.
+func writeEnqueued(w http.ResponseWriter, r *http.Request, taskID string, waitTimeout time.Duration) {
+	if waitTimeout == 0 {
+		writeJSON(w, http.StatusOK, enqueueResponse(taskID))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), waitTimeout)
+	defer cancel()
+
+	status, err := queue.GetManager().Wait(ctx, taskID)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, ErrorResponse{Error: "QUEUE_ERROR", Message: err.Error(), TaskID: taskID})
+		return
+	}
+
+	switch status.State {
+	case queue.TaskSent:
+		writeJSON(w, http.StatusOK, &EnqueueResponse{Success: true, TaskID: taskID, MessageID: status.MessageID})
+	case queue.TaskFailed:
+		writeJSON(w, http.StatusBadGateway, ErrorResponse{Error: "SERVICE_ERROR", Message: status.LastError, TaskID: taskID})
+	case queue.TaskDropped, queue.TaskCoalesced:
+		writeJSON(w, http.StatusServiceUnavailable, ErrorResponse{Error: "QUEUE_FULL", Message: "task was " + string(status.State), TaskID: taskID})
+	default:
+		writeJSON(w, http.StatusGatewayTimeout, ErrorResponse{Error: "TIMEOUT", Message: "task is still " + string(status.State), TaskID: taskID})
+	}
+}
+
+// enqueueResponse reports an accepted task, flagging tasks the coalesce
+// overflow policy folded into a summary.
+func enqueueResponse(taskID string) *EnqueueResponse {
+	resp := &EnqueueResponse{Success: true, TaskID: taskID}
+	if status, err := queue.GetManager().TaskStatus(taskID); err == nil && status.State == queue.TaskCoalesced {
+		resp.Coalesced = true
+	}
+	return resp
+}
+
+// writeEnqueueError maps queue errors to responses; a full queue becomes 429
+// with Retry-After.
+func writeEnqueueError(w http.ResponseWriter, err error) {
+	var fullErr *queue.QueueFullError
+	if errors.As(err, &fullErr) {
+		w.Header().Set("Retry-After", strconv.Itoa(int(fullErr.RetryAfter.Seconds())))
+		writeError(w, http.StatusTooManyRequests, "QUEUE_FULL", err.Error())
+		return
+	}
+	writeError(w, http.StatusInternalServerError, "QUEUE_ERROR", err.Error())
+}
+
+// resolveService validates channel/target, checks the API key may use them and
+// returns the corresponding service. Writes an error response and returns
+// false if validation fails.
+func resolveService(w http.ResponseWriter, r *http.Request, channelStr, target string) (service.Channel, service.NotifyService, bool) {
 	if channelStr == "" || target == "" {
 		writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", "channel and target are required")
 		return "", nil, false
@@ -124,6 +322,10 @@ func resolveService(w http.ResponseWriter, channelStr, target string) (service.C
 		return "", nil, false
 	}
 
+	if !authorizeTarget(w, r, string(channel), target) {
+		return "", nil, false
+	}
+
 	svc, err := service.GetService(channel)
 	if err != nil {
 		writeError(w, http.StatusNotFound, "NOT_FOUND", err.Error())
@@ -0,0 +1,38 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"notify/internal/config"
+	"notify/internal/service"
+)
+
+func TestSendMessageToUnconfiguredChannel(t *testing.T) {
+	if err := service.Init(&config.Config{Telegram: config.TelegramConfig{BotToken: "test"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		channel string
+		want    int
+		code    string
+	}{
+		{channel: "feishu", want: http.StatusNotFound, code: "NOT_FOUND"},
+		{channel: "sms", want: http.StatusBadRequest, code: "VALIDATION_ERROR"},
+	}
+	for _, tt := range tests {
+		body := `{"channel":"` + tt.channel + `","target":"oc_123","params":{"content":"hi"}}`
+		w := httptest.NewRecorder()
+		SendMessage(w, httptest.NewRequest(http.MethodPost, "/api/messages", strings.NewReader(body)))
+
+		var resp ErrorResponse
+		_ = json.Unmarshal(w.Body.Bytes(), &resp)
+		if w.Code != tt.want || resp.Error != tt.code {
+			t.Fatalf("%s: status = %d %s, want %d %s", tt.channel, w.Code, w.Body, tt.want, tt.code)
+		}
+	}
+}
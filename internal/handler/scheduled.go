@@ -0,0 +1,38 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"notify/internal/queue"
+	"notify/internal/service"
+)
+
+func ListScheduled(w http.ResponseWriter, r *http.Request) {
+	tasks := queue.GetManager().Scheduled()
+	visible := make([]*queue.Task, 0, len(tasks))
+	for _, task := range tasks {
+		if keyAllows(r, string(task.Channel), task.Target) {
+			visible = append(visible, task)
+		}
+	}
+	writeJSON(w, http.StatusOK, visible)
+}
+
+func CancelScheduled(w http.ResponseWriter, r *http.Request) {
+	if !authorizeTask(w, r, r.PathValue("id")) {
+		return
+	}
+
+	err := queue.GetManager().CancelScheduled(r.PathValue("id"))
+	if errors.Is(err, queue.ErrTaskNotFound) {
+		writeError(w, http.StatusNotFound, "NOT_FOUND", err.Error())
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "QUEUE_ERROR", err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, &service.SendResult{Success: true})
+}
@@ -0,0 +1,71 @@
+package handler
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"notify/internal/config"
+)
+
+func TestVerifySignature(t *testing.T) {
+	cfg := config.SignatureConfig{
+		Secret:          "s3cret",
+		Header:          "X-Grafana-Alerting-Signature",
+		TimestampHeader: "X-Grafana-Alerting-Timestamp",
+		Tolerance:       5 * time.Minute,
+	}
+	nextStatus := http.StatusOK
+	h := VerifySignature(cfg, func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if string(body) != `{"status":"firing"}` {
+			t.Errorf("next saw body %q", body)
+		}
+		w.WriteHeader(nextStatus)
+	})
+
+	sign := func(timestamp, body string) string {
+		mac := hmac.New(sha256.New, []byte(cfg.Secret))
+		mac.Write([]byte(timestamp + ":" + body))
+		return hex.EncodeToString(mac.Sum(nil))
+	}
+	do := func(timestamp, signature string) int {
+		r := httptest.NewRequest(http.MethodPost, "/api/webhooks/grafana", strings.NewReader(`{"status":"firing"}`))
+		r.Header.Set(cfg.Header, signature)
+		r.Header.Set(cfg.TimestampHeader, timestamp)
+		w := httptest.NewRecorder()
+		h(w, r)
+		return w.Code
+	}
+
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	stale := strconv.FormatInt(time.Now().Add(-time.Hour).Unix(), 10)
+	body := `{"status":"firing"}`
+
+	if code := do(now, "deadbeef"); code != http.StatusUnauthorized {
+		t.Fatalf("bad signature: status = %d, want 401", code)
+	}
+	if code := do(stale, sign(stale, body)); code != http.StatusUnauthorized {
+		t.Fatalf("stale timestamp: status = %d, want 401", code)
+	}
+
+	// A request the handler failed may be retried with the same signature.
+	nextStatus = http.StatusTooManyRequests
+	if code := do(now, sign(now, body)); code != http.StatusTooManyRequests {
+		t.Fatalf("failed request: status = %d, want 429", code)
+	}
+	nextStatus = http.StatusOK
+	if code := do(now, sign(now, body)); code != http.StatusOK {
+		t.Fatalf("valid signature: status = %d, want 200", code)
+	}
+	if code := do(now, sign(now, body)); code != http.StatusUnauthorized {
+		t.Fatalf("replay: status = %d, want 401", code)
+	}
+}
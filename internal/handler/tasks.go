@@ -0,0 +1,27 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"notify/internal/queue"
+)
+
+func GetTask(w http.ResponseWriter, r *http.Request) {
+	status, err := queue.GetManager().TaskStatus(r.PathValue("id"))
+	if errors.Is(err, queue.ErrTaskNotFound) {
+		writeError(w, http.StatusNotFound, "NOT_FOUND", err.Error())
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "QUEUE_ERROR", err.Error())
+		return
+	}
+	// Hide tasks for targets outside the key's scope rather than confirm they exist.
+	if !keyAllows(r, status.Channel, status.Target) {
+		writeError(w, http.StatusNotFound, "NOT_FOUND", queue.ErrTaskNotFound.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, status)
+}
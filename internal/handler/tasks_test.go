@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"notify/internal/config"
+	"notify/internal/queue"
+	"notify/internal/service"
+)
+
+// initQueue starts a queue manager with the Telegram service registered.
+func initQueue(t *testing.T) *queue.Manager {
+	t.Helper()
+	if err := service.Init(&config.Config{Telegram: config.TelegramConfig{BotToken: "test"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := queue.Init(config.QueueConfig{BufferSize: 10, IdleTimeout: time.Minute, StatusRetention: time.Hour}); err != nil {
+		t.Fatal(err)
+	}
+	m := queue.GetManager()
+	t.Cleanup(m.Shutdown)
+	return m
+}
+
+func TestGetTask(t *testing.T) {
+	m := initQueue(t)
+	taskID, err := m.Enqueue(service.ChannelTelegram, "-100", map[string]any{"text": "hi"},
+		queue.EnqueueOptions{SendAt: time.Now().Add(time.Hour)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/tasks/{id}", GetTask)
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/tasks/"+taskID, nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body)
+	}
+	var status queue.TaskStatus
+	if err := json.Unmarshal(w.Body.Bytes(), &status); err != nil {
+		t.Fatal(err)
+	}
+	if status.ID != taskID || status.State != queue.TaskScheduled || status.Channel != "telegram" || status.Target != "-100" {
+		t.Fatalf("task = %#v", status)
+	}
+
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/tasks/task_unknown", nil))
+	var resp ErrorResponse
+	_ = json.Unmarshal(w.Body.Bytes(), &resp)
+	if w.Code != http.StatusNotFound || resp.Error != "NOT_FOUND" {
+		t.Fatalf("unknown task = %d %s", w.Code, w.Body)
+	}
+}
@@ -31,12 +31,24 @@ func HandleGrafanaWebhook(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !authorizeTarget(w, r, string(channel), target) {
+		return
+	}
+
+	priorityStr := r.URL.Query().Get("priority")
+	if _, err := queue.ParsePriority(priorityStr); err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", err.Error())
+		return
+	}
+
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
 		writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", "Failed to read request body")
 		return
 	}
 
+	logRequestBody(r, body)
+
 	alert, err := decodeGrafanaAlert(body)
 	if err != nil {
 		writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid request body")
@@ -51,18 +63,39 @@ func HandleGrafanaWebhook(w http.ResponseWriter, r *http.Request) {
 		"bodyBytes", len(body),
 	)
 
-	_, err = service.GetService(channel)
+	svc, err := service.GetService(channel)
 	if err != nil {
 		writeError(w, http.StatusNotFound, "NOT_FOUND", err.Error())
 		return
 	}
 
-	message := formatGrafanaAlert(channel, alert)
-	queue.GetManager().Enqueue(channel, target, message)
-
-	writeJSON(w, http.StatusOK, &service.SendResult{
-		Success: true,
+	message := formatGrafanaAlert(svc, alert)
+	taskID, err := queue.GetManager().Enqueue(channel, target, message, queue.EnqueueOptions{
+		DedupKey: r.Header.Get("Idempotency-Key"),
+		Priority: grafanaPriority(priorityStr, alert),
 	})
+	if err != nil {
+		writeEnqueueError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, enqueueResponse(taskID))
+}
+
+// grafanaPriority uses the priority query param when given; otherwise firing
+// alerts jump ahead of other messages and reports yield to them.
+func grafanaPriority(requested string, alert grafanaNotification) queue.Priority {
+	if requested != "" {
+		return queue.Priority(requested)
+	}
+	switch {
+	case alert.NotificationType == grafanaNotificationTypeReport:
+		return queue.PriorityLow
+	case alert.State == "alerting":
+		return queue.PriorityHigh
+	default:
+		return queue.PriorityNormal
+	}
 }
 
 type grafanaWebhook struct {
@@ -296,15 +329,46 @@ func meaningful(value string) string {
 	}
 }
 
-func formatGrafanaAlert(channel service.Channel, alert grafanaNotification) any {
-	switch channel {
+// formatGrafanaAlert uses a dedicated layout where one exists and otherwise
+// lets the service build its standard message from grafanaMessageParams.
+func formatGrafanaAlert(svc service.NotifyService, alert grafanaNotification) any {
+	switch svc.Channel() {
+	case service.ChannelFeishu:
+		return formatGrafanaAlertForFeishu(alert)
 	case service.ChannelTelegram:
 		return formatGrafanaAlertForTelegram(alert)
+	case service.ChannelTeams:
+		return formatGrafanaAlertForTeams(alert)
 	default:
-		return formatGrafanaAlertForFeishu(alert)
+		return svc.BuildMessage(grafanaMessageParams(alert))
 	}
 }
 
+// grafanaMessageParams maps an alert onto the generic message fields: the
+// rule as title, matches as content and the rule message as note.
+func grafanaMessageParams(alert grafanaNotification) service.MessageParams {
+	params := service.MessageParams{Title: alert.RuleName, Note: alert.Message}
+	switch {
+	case alert.NotificationType == grafanaNotificationTypeReport:
+		params.Color = service.ColorBlue
+	case alert.State == "alerting":
+		params.Color = service.ColorOrange
+		params.Title = "⚠️ " + alert.RuleName
+	case alert.State == "ok":
+		params.Color = service.ColorGreen
+		params.Title = "✅ " + alert.RuleName
+	default:
+		params.Color = service.ColorGrey
+	}
+
+	items := make([]string, len(alert.Matches))
+	for i, item := range alert.Matches {
+		items[i] = item.Summary
+	}
+	params.Content = strings.Join(items, "\n")
+	return params
+}
+
 func formatGrafanaAlertForFeishu(alert grafanaNotification) map[string]any {
 	elements := []any{}
 	var template, title string
@@ -395,3 +459,26 @@ func formatGrafanaAlertForTelegram(alert grafanaNotification) map[string]any {
 		"parse_mode": "HTML",
 	}
 }
+
+func formatGrafanaAlertForTeams(alert grafanaNotification) map[string]any {
+	params := grafanaMessageParams(alert)
+
+	body := []any{service.TeamsTitle(params.Title, params.Color)}
+
+	// One block per match keeps long summaries from running together.
+	for i, item := range alert.Matches {
+		block := map[string]any{"type": "TextBlock", "text": item.Summary, "wrap": true}
+		if i > 0 {
+			block["spacing"] = "None"
+		}
+		body = append(body, block)
+	}
+
+	footer := alert.Message
+	if footer == "" {
+		footer = time.Now().UTC().Format("2006-01-02 15:04:05 UTC")
+	}
+	body = append(body, service.TeamsNote(footer))
+
+	return service.TeamsCardMessage(body, nil)
+}
@@ -348,6 +348,37 @@ func TestFormatGrafanaResolvedAlertForFeishuUsesGreen(t *testing.T) {
 	}
 }
 
+func TestFormatGrafanaAlertForTeams(t *testing.T) {
+	alert := grafanaNotification{
+		State:            "alerting",
+		RuleName:         "Position mismatch",
+		NotificationType: grafanaNotificationTypeAlert,
+		Message:          "Current position difference exceeds the threshold",
+		Matches: []grafanaMatch{
+			{Summary: "ROAM, position: 58816.2444"},
+			{Summary: "H, position: 6839.5352"},
+		},
+	}
+
+	message := formatGrafanaAlertForTeams(alert)
+	attachment := message["attachments"].([]any)[0].(map[string]any)
+	body := attachment["content"].(map[string]any)["body"].([]any)
+	if len(body) != 4 {
+		t.Fatalf("body = %#v", body)
+	}
+	title := body[0].(map[string]any)
+	if title["text"] != "⚠️ Position mismatch" || title["color"] != "warning" {
+		t.Fatalf("title = %#v", title)
+	}
+	if body[2].(map[string]any)["text"] != "H, position: 6839.5352" {
+		t.Fatalf("match = %#v", body[2])
+	}
+	footer := body[3].(map[string]any)
+	if footer["text"] != alert.Message || footer["isSubtle"] != true {
+		t.Fatalf("footer = %#v", footer)
+	}
+}
+
 func TestDecodeGrafanaAlertRejectsUnsupportedPayload(t *testing.T) {
 	if _, err := decodeGrafanaAlert([]byte(`{"foo":"bar"}`)); err == nil {
 		t.Fatal("decodeGrafanaAlert() error = nil, want unsupported payload error")
@@ -0,0 +1,126 @@
+// Package logging scrubs secrets from log records before they are written.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+)
+
+const redacted = "[REDACTED]"
+
+// DefaultPatterns catch credentials that commonly end up in URLs, headers and
+// pasted message text. The first capture group, if any, is kept.
+var DefaultPatterns = []string{
+	// Telegram bot tokens, also inside api.telegram.org/bot<token>/ URLs.
+	`\d{6,}:[A-Za-z0-9_-]{30,}`,
+	// Discord webhook tokens, in URLs and "id/token" targets.
+	`(webhooks/\d+/|\b\d{17,20}/)[A-Za-z0-9_-]{60,}`,
+	`(?i)(bearer\s+)[A-Za-z0-9._~+/=-]+`,
+	`(?i)((?:password|passwd|secret|token|api[_-]?key|access[_-]?key)["']?\s*[=:]\s*["']?)[^\s&"',;]+`,
+}
+
+// Redactor replaces known secret values and pattern matches.
+type Redactor struct {
+	patterns []*regexp.Regexp
+	secrets  *strings.Replacer
+}
+
+// NewRedactor compiles patterns and records literal secrets such as
+// configured tokens. Empty secrets are ignored.
+func NewRedactor(patterns []string, secrets ...string) (*Redactor, error) {
+	r := &Redactor{}
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redaction pattern %q: %w", p, err)
+		}
+		r.patterns = append(r.patterns, re)
+	}
+
+	var pairs []string
+	for _, secret := range secrets {
+		if secret != "" {
+			pairs = append(pairs, secret, redacted)
+		}
+	}
+	if len(pairs) > 0 {
+		r.secrets = strings.NewReplacer(pairs...)
+	}
+	return r, nil
+}
+
+func (r *Redactor) Redact(s string) string {
+	if r.secrets != nil {
+		s = r.secrets.Replace(s)
+	}
+	for _, re := range r.patterns {
+		if re.NumSubexp() > 0 {
+			s = re.ReplaceAllString(s, "${1}"+redacted)
+		} else {
+			s = re.ReplaceAllLiteralString(s, redacted)
+		}
+	}
+	return s
+}
+
+// Handler redacts the message and every string, error and Stringer attribute
+// before passing records to the wrapped handler.
+type Handler struct {
+	inner    slog.Handler
+	redactor *Redactor
+}
+
+func NewHandler(inner slog.Handler, redactor *Redactor) *Handler {
+	return &Handler{inner: inner, redactor: redactor}
+}
+
+func (h *Handler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.inner.Enabled(ctx, level)
+}
+
+func (h *Handler) Handle(ctx context.Context, record slog.Record) error {
+	out := slog.NewRecord(record.Time, record.Level, h.redactor.Redact(record.Message), record.PC)
+	record.Attrs(func(a slog.Attr) bool {
+		out.AddAttrs(h.redactAttr(a))
+		return true
+	})
+	return h.inner.Handle(ctx, out)
+}
+
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	redactedAttrs := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		redactedAttrs[i] = h.redactAttr(a)
+	}
+	return &Handler{inner: h.inner.WithAttrs(redactedAttrs), redactor: h.redactor}
+}
+
+func (h *Handler) WithGroup(name string) slog.Handler {
+	return &Handler{inner: h.inner.WithGroup(name), redactor: h.redactor}
+}
+
+func (h *Handler) redactAttr(a slog.Attr) slog.Attr {
+	v := a.Value.Resolve()
+	switch v.Kind() {
+	case slog.KindString:
+		return slog.String(a.Key, h.redactor.Redact(v.String()))
+	case slog.KindGroup:
+		group := v.Group()
+		attrs := make([]any, len(group))
+		for i, ga := range group {
+			attrs[i] = h.redactAttr(ga)
+		}
+		return slog.Group(a.Key, attrs...)
+	case slog.KindAny:
+		switch x := v.Any().(type) {
+		case error:
+			return slog.String(a.Key, h.redactor.Redact(x.Error()))
+		case fmt.Stringer:
+			return slog.String(a.Key, h.redactor.Redact(x.String()))
+		}
+	}
+	return slog.Attr{Key: a.Key, Value: v}
+}
@@ -0,0 +1,51 @@
+package logging
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestRedactor(t *testing.T) {
+	r, err := NewRedactor(append(DefaultPatterns, `oc_[0-9a-f]{8}`), "feishu-app-secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct{ in, want string }{
+		{"secret is feishu-app-secret", "secret is [REDACTED]"},
+		{"https://api.telegram.org/bot123456789:AAHdqTcvCH1vGWJxfSeofSAs0K5PALDsaw/sendMessage",
+			"https://api.telegram.org/bot[REDACTED]/sendMessage"},
+		{"Authorization: Bearer abc.def-123", "Authorization: Bearer [REDACTED]"},
+		{"https://host/hook?token=abc123&x=1", "https://host/hook?token=[REDACTED]&x=1"},
+		{`{"password": "hunter2"}`, `{"password": "[REDACTED]"}`},
+		{"chat oc_0123abcd", "chat [REDACTED]"},
+		{"nothing to hide", "nothing to hide"},
+	}
+	for _, tt := range tests {
+		if got := r.Redact(tt.in); got != tt.want {
+			t.Errorf("Redact(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+
+	if _, err := NewRedactor([]string{"("}); err == nil {
+		t.Error("NewRedactor accepted an invalid pattern")
+	}
+}
+
+func TestHandlerRedactsRecords(t *testing.T) {
+	r, err := NewRedactor(nil, "s3cret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	logger := slog.New(NewHandler(slog.NewTextHandler(&buf, nil), r)).With("key", "s3cret")
+	logger.Info("token s3cret", "error", errors.New("failed with s3cret"), slog.Group("req", "body", "s3cret"))
+
+	if out := buf.String(); strings.Contains(out, "s3cret") {
+		t.Fatalf("log output leaked the secret: %s", out)
+	}
+}
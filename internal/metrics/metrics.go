@@ -0,0 +1,271 @@
+// Package metrics implements the small subset of the Prometheus text
+// exposition format this service needs: labelled counters, histograms and
+// gauges computed at scrape time.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefBuckets suits request latencies in seconds.
+var DefBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+type collector interface {
+	write(w io.Writer)
+}
+
+var (
+	registryMu sync.Mutex
+	registry   []collector
+)
+
+func register(c collector) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry = append(registry, c)
+}
+
+// WriteTo writes every registered metric in the text exposition format.
+func WriteTo(w io.Writer) {
+	registryMu.Lock()
+	collectors := slices.Clone(registry)
+	registryMu.Unlock()
+
+	for _, c := range collectors {
+		c.write(w)
+	}
+}
+
+// Handler serves the registered metrics.
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		bw := bufio.NewWriter(w)
+		WriteTo(bw)
+		_ = bw.Flush()
+	})
+}
+
+// series holds the label values of one time series, in label name order.
+type series []string
+
+func (s series) key() string {
+	return strings.Join(s, "\xff")
+}
+
+type CounterVec struct {
+	name   string
+	help   string
+	labels []string
+
+	mu     sync.Mutex
+	series map[string]series
+	values map[string]float64
+}
+
+func NewCounterVec(name, help string, labels ...string) *CounterVec {
+	c := &CounterVec{
+		name:   name,
+		help:   help,
+		labels: labels,
+		series: make(map[string]series),
+		values: make(map[string]float64),
+	}
+	register(c)
+	return c
+}
+
+func (c *CounterVec) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+func (c *CounterVec) Add(v float64, labelValues ...string) {
+	s := series(labelValues)
+	key := s.key()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.series[key]; !ok {
+		c.series[key] = slices.Clone(s)
+	}
+	c.values[key] += v
+}
+
+func (c *CounterVec) write(w io.Writer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	writeHeader(w, c.name, c.help, "counter")
+	for _, key := range sortedKeys(c.values) {
+		fmt.Fprintf(w, "%s%s %s\n", c.name, formatLabels(c.labels, c.series[key], "", ""), formatValue(c.values[key]))
+	}
+}
+
+type HistogramVec struct {
+	name    string
+	help    string
+	labels  []string
+	buckets []float64
+
+	mu     sync.Mutex
+	series map[string]series
+	values map[string]*histogram
+}
+
+type histogram struct {
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+// NewHistogramVec creates a histogram with the given upper bucket bounds,
+// which must be sorted; the +Inf bucket is implied.
+func NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	h := &HistogramVec{
+		name:    name,
+		help:    help,
+		labels:  labels,
+		buckets: buckets,
+		series:  make(map[string]series),
+		values:  make(map[string]*histogram),
+	}
+	register(h)
+	return h
+}
+
+func (h *HistogramVec) Observe(v float64, labelValues ...string) {
+	s := series(labelValues)
+	key := s.key()
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	hist, ok := h.values[key]
+	if !ok {
+		hist = &histogram{counts: make([]uint64, len(h.buckets))}
+		h.series[key] = slices.Clone(s)
+		h.values[key] = hist
+	}
+	for i, bound := range h.buckets {
+		if v <= bound {
+			hist.counts[i]++
+		}
+	}
+	hist.count++
+	hist.sum += v
+}
+
+func (h *HistogramVec) write(w io.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	writeHeader(w, h.name, h.help, "histogram")
+	for _, key := range sortedKeys(h.values) {
+		hist, s := h.values[key], h.series[key]
+		for i, bound := range h.buckets {
+			fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, formatLabels(h.labels, s, "le", formatValue(bound)), hist.counts[i])
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, formatLabels(h.labels, s, "le", "+Inf"), hist.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", h.name, formatLabels(h.labels, s, "", ""), formatValue(hist.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", h.name, formatLabels(h.labels, s, "", ""), hist.count)
+	}
+}
+
+// GaugeFunc reports values computed when the metrics are scraped.
+type GaugeFunc struct {
+	name    string
+	help    string
+	labels  []string
+	collect func(emit func(v float64, labelValues ...string))
+}
+
+// NewGaugeFunc registers a gauge whose series are produced by collect, which
+// calls emit once per series.
+func NewGaugeFunc(name, help string, collect func(emit func(v float64, labelValues ...string)), labels ...string) *GaugeFunc {
+	g := &GaugeFunc{name: name, help: help, labels: labels, collect: collect}
+	register(g)
+	return g
+}
+
+func (g *GaugeFunc) write(w io.Writer) {
+	values := make(map[string]float64)
+	seen := make(map[string]series)
+	g.collect(func(v float64, labelValues ...string) {
+		s := series(labelValues)
+		values[s.key()] += v
+		seen[s.key()] = slices.Clone(s)
+	})
+
+	writeHeader(w, g.name, g.help, "gauge")
+	for _, key := range sortedKeys(values) {
+		fmt.Fprintf(w, "%s%s %s\n", g.name, formatLabels(g.labels, seen[key], "", ""), formatValue(values[key]))
+	}
+}
+
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+)
+
+func writeHeader(w io.Writer, name, help, typ string) {
+	fmt.Fprintf(w, "# HELP %s %s\n", name, helpEscaper.Replace(help))
+	fmt.Fprintf(w, "# TYPE %s %s\n", name, typ)
+}
+
+// formatLabels renders {name="value",...}, appending an extra label such as
+// le when extraName is set.
+func formatLabels(names []string, values series, extraName, extraValue string) string {
+	if len(names) == 0 && extraName == "" {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, name := range names {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		value := ""
+		if i < len(values) {
+			value = values[i]
+		}
+		b.WriteString(name + `="` + labelEscaper.Replace(value) + `"`)
+	}
+	if extraName != "" {
+		if len(names) > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(extraName + `="` + labelEscaper.Replace(extraValue) + `"`)
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+func formatValue(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
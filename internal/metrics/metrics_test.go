@@ -0,0 +1,51 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWriteTo(t *testing.T) {
+	registryMu.Lock()
+	saved := registry
+	registry = nil
+	registryMu.Unlock()
+	t.Cleanup(func() {
+		registryMu.Lock()
+		registry = saved
+		registryMu.Unlock()
+	})
+
+	sent := NewCounterVec("test_sent_total", "Sent tasks.", "channel")
+	sent.Inc("telegram")
+	sent.Add(2, "feishu")
+	latency := NewHistogramVec("test_latency_seconds", "Latency.", []float64{0.1, 1}, "channel")
+	latency.Observe(0.5, "telegram")
+	latency.Observe(3, "telegram")
+	NewGaugeFunc("test_depth", "Depth.", func(emit func(float64, ...string)) {
+		emit(2, `we"ird`)
+		emit(3, `we"ird`)
+	}, "channel")
+
+	var b strings.Builder
+	WriteTo(&b)
+
+	want := `# HELP test_sent_total Sent tasks.
+# TYPE test_sent_total counter
+test_sent_total{channel="feishu"} 2
+test_sent_total{channel="telegram"} 1
+# HELP test_latency_seconds Latency.
+# TYPE test_latency_seconds histogram
+test_latency_seconds_bucket{channel="telegram",le="0.1"} 0
+test_latency_seconds_bucket{channel="telegram",le="1"} 1
+test_latency_seconds_bucket{channel="telegram",le="+Inf"} 2
+test_latency_seconds_sum{channel="telegram"} 3.5
+test_latency_seconds_count{channel="telegram"} 2
+# HELP test_depth Depth.
+# TYPE test_depth gauge
+test_depth{channel="we\"ird"} 5
+`
+	if got := b.String(); got != want {
+		t.Fatalf("WriteTo() =\n%s\nwant\n%s", got, want)
+	}
+}
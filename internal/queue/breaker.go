@@ -0,0 +1,214 @@
+package queue
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"notify/internal/service"
+)
+
+type BreakerState string
+
+const (
+	BreakerClosed   BreakerState = "closed"
+	BreakerOpen     BreakerState = "open"
+	BreakerHalfOpen BreakerState = "half_open"
+)
+
+// breaker stops sending to a destination after consecutive failures and lets
+// a single probe through once the cooldown has passed.
+type breaker struct {
+	name      string
+	threshold int
+	cooldown  time.Duration
+
+	mu       sync.Mutex
+	state    BreakerState
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+type BreakerStatus struct {
+	Name     string       `json:"name"`
+	State    BreakerState `json:"state"`
+	Failures int          `json:"failures"`
+	OpenedAt time.Time    `json:"openedAt,omitzero"`
+	RetryAt  time.Time    `json:"retryAt,omitzero"`
+}
+
+func newBreaker(name string, threshold int, cooldown time.Duration) *breaker {
+	return &breaker{name: name, threshold: threshold, cooldown: cooldown, state: BreakerClosed}
+}
+
+// allow reports whether a send may go ahead. When it may not, it returns how
+// long to wait before asking again.
+func (b *breaker) allow(now time.Time) (bool, time.Duration) {
+	if b.threshold <= 0 {
+		return true, 0
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case BreakerOpen:
+		if retryAt := b.openedAt.Add(b.cooldown); now.Before(retryAt) {
+			return false, retryAt.Sub(now)
+		}
+		b.state = BreakerHalfOpen
+		b.probing = true
+		return true, 0
+	case BreakerHalfOpen:
+		if b.probing {
+			return false, b.cooldown
+		}
+		b.probing = true
+		return true, 0
+	default:
+		return true, 0
+	}
+}
+
+func (b *breaker) success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.state = BreakerClosed
+	b.failures = 0
+	b.probing = false
+}
+
+func (b *breaker) failure(now time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	b.probing = false
+	if b.state == BreakerHalfOpen || (b.threshold > 0 && b.failures >= b.threshold) {
+		b.state = BreakerOpen
+		b.openedAt = now
+	}
+}
+
+// release ends a probe whose outcome said nothing about the destination's
+// health, such as a rate limit, so another probe may follow.
+func (b *breaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+}
+
+func (b *breaker) status() BreakerStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	status := BreakerStatus{Name: b.name, State: b.state, Failures: b.failures}
+	if b.state != BreakerClosed {
+		status.OpenedAt = b.openedAt
+		status.RetryAt = b.openedAt.Add(b.cooldown)
+	}
+	return status
+}
+
+// targetBreaker returns the breaker of the target queue with key.
+// Caller must hold m.mu.
+func (m *Manager) targetBreaker(key string) *breaker {
+	b, ok := m.targetBreakers[key]
+	if !ok {
+		b = newBreaker(key, m.cfg.BreakerThreshold, m.cfg.BreakerCooldown)
+		m.targetBreakers[key] = b
+	}
+	return b
+}
+
+// pruneBreakers forgets closed breakers of targets without a queue.
+func (m *Manager) pruneBreakers() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for key, b := range m.targetBreakers {
+		if _, ok := m.queues[key]; !ok && b.status().State == BreakerClosed {
+			delete(m.targetBreakers, key)
+		}
+	}
+}
+
+// Breakers returns the state of every channel-wide and per-target breaker.
+func (m *Manager) Breakers() []BreakerStatus {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	statuses := make([]BreakerStatus, 0, len(m.breakers)+len(m.targetBreakers))
+	for _, b := range m.breakers {
+		statuses = append(statuses, b.status())
+	}
+	for _, b := range m.targetBreakers {
+		statuses = append(statuses, b.status())
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Name < statuses[j].Name })
+	return statuses
+}
+
+// allow checks the target breaker and then the channel breaker.
+func (tq *targetQueue) allow(now time.Time) (bool, time.Duration) {
+	if ok, wait := tq.breaker.allow(now); !ok {
+		return false, wait
+	}
+	if ok, wait := tq.channelBreaker.allow(now); !ok {
+		tq.breaker.release()
+		return false, wait
+	}
+	return true, 0
+}
+
+// record updates both breakers with the outcome of a send. Any response from
+// the platform, even a permanent error for this target, shows the channel is
+// up; rate limits say nothing about the target's health.
+func (tq *targetQueue) record(err error) {
+	if err == nil {
+		tq.breaker.success()
+		tq.channelBreaker.success()
+		return
+	}
+
+	now := time.Now()
+	switch kind, _ := service.ClassifyError(err); kind {
+	case service.ErrorRateLimited:
+		tq.breaker.release()
+		tq.channelBreaker.success()
+	case service.ErrorPermanent:
+		tq.breaker.failure(now)
+		tq.channelBreaker.success()
+	default:
+		tq.breaker.failure(now)
+		tq.channelBreaker.failure(now)
+	}
+}
+
+// awaitBreakers parks the task until both breakers let a send through. It
+// returns false if ctx is done first.
+func (m *Manager) awaitBreakers(ctx context.Context, tq *targetQueue, task *Task) bool {
+	parked := false
+	for {
+		ok, wait := tq.allow(time.Now())
+		if ok {
+			return true
+		}
+
+		if !parked {
+			parked = true
+			m.track(task, TaskParked, "")
+			slog.Warn("Circuit open, task parked", "taskId", task.ID, "key", tq.key, "retryIn", wait)
+		}
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(wait):
+		}
+	}
+}
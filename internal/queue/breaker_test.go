@@ -0,0 +1,73 @@
+package queue
+
+import (
+	"testing"
+	"time"
+
+	"notify/internal/config"
+)
+
+func TestBreakerOpensAndProbes(t *testing.T) {
+	b := newBreaker("telegram:-100", 2, time.Minute)
+	now := time.Now()
+
+	b.failure(now)
+	if ok, _ := b.allow(now); !ok {
+		t.Fatal("breaker opened below threshold")
+	}
+	b.failure(now)
+	if ok, wait := b.allow(now.Add(time.Second)); ok || wait != 59*time.Second {
+		t.Fatalf("allow = %v, %s; want false, 59s while open", ok, wait)
+	}
+
+	// After the cooldown exactly one probe goes through.
+	later := now.Add(time.Minute)
+	if ok, _ := b.allow(later); !ok {
+		t.Fatal("probe rejected after cooldown")
+	}
+	if ok, _ := b.allow(later); ok {
+		t.Fatal("second probe admitted while half-open")
+	}
+
+	b.failure(later)
+	if got := b.status(); got.State != BreakerOpen || !got.OpenedAt.Equal(later) {
+		t.Fatalf("status = %+v, want reopened at %s", got, later)
+	}
+
+	b.allow(later.Add(time.Minute))
+	b.success()
+	if got := b.status(); got.State != BreakerClosed || got.Failures != 0 {
+		t.Fatalf("status = %+v, want closed after successful probe", got)
+	}
+}
+
+func TestBreakerDisabled(t *testing.T) {
+	b := newBreaker("telegram", 0, time.Minute)
+	for range 10 {
+		b.failure(time.Now())
+	}
+	if ok, _ := b.allow(time.Now()); !ok {
+		t.Fatal("disabled breaker rejected a send")
+	}
+}
+
+func TestTargetBreakerOutlivesIdleQueue(t *testing.T) {
+	m := newManager(config.QueueConfig{BreakerThreshold: 1, BreakerCooldown: time.Minute}, nopStore{}, newMemStore())
+	tq := newTestQueue(m, &fakeService{}, 1)
+	tq.breaker.failure(time.Now())
+
+	// The idle worker removes the queue; the next task recreates it.
+	delete(m.queues, tq.key)
+	m.pruneBreakers()
+	tq = newTestQueue(m, &fakeService{}, 1)
+	if ok, _ := tq.allow(time.Now()); ok {
+		t.Fatal("recreated queue lost its open breaker")
+	}
+
+	tq.breaker.success()
+	delete(m.queues, tq.key)
+	m.pruneBreakers()
+	if len(m.targetBreakers) != 0 {
+		t.Fatalf("targetBreakers = %v, want closed breaker pruned", m.targetBreakers)
+	}
+}
@@ -0,0 +1,161 @@
+package queue
+
+import (
+	"errors"
+	"log/slog"
+	"slices"
+	"time"
+
+	"notify/internal/service"
+)
+
+var ErrTaskNotFound = errors.New("task not found")
+
+// deadEntry orders dead letters by failure time so the oldest can be
+// discarded without reading the dead-letter store.
+type deadEntry struct {
+	id       string
+	failedAt time.Time
+}
+
+// loadDeadLetters indexes the dead letters kept by a previous run.
+func (m *Manager) loadDeadLetters() {
+	tasks, err := m.dead.Pending()
+	if err != nil {
+		slog.Error("Failed to load dead letters", "error", err)
+		return
+	}
+	for _, task := range tasks {
+		m.indexDeadLetter(task)
+	}
+}
+
+// indexDeadLetter records task in the failure-time index. Caller must hold
+// m.deadMu unless no other goroutine can reach m yet.
+func (m *Manager) indexDeadLetter(task *Task) {
+	at := len(m.deadIndex)
+	for at > 0 && m.deadIndex[at-1].failedAt.After(task.FailedAt) {
+		at--
+	}
+	m.deadIndex = slices.Insert(m.deadIndex, at, deadEntry{id: task.ID, failedAt: task.FailedAt})
+}
+
+// unindexDeadLetter removes id from the failure-time index. Caller must hold
+// m.deadMu.
+func (m *Manager) unindexDeadLetter(id string) {
+	m.deadIndex = slices.DeleteFunc(m.deadIndex, func(e deadEntry) bool { return e.id == id })
+}
+
+// deadLetter keeps a task that will not be retried automatically so it can be
+// inspected and replayed later. Beyond DeadLetterMax the earliest failures
+// are discarded.
+func (m *Manager) deadLetter(task *Task) {
+	task.FailedAt = time.Now()
+	m.track(task, TaskFailed, "")
+
+	m.deadMu.Lock()
+	if err := m.dead.Put(task); err != nil {
+		slog.Error("Failed to store dead letter", "taskId", task.ID, "error", err)
+	} else {
+		m.indexDeadLetter(task)
+	}
+	var discard []deadEntry
+	if m.cfg.DeadLetterMax > 0 && len(m.deadIndex) > m.cfg.DeadLetterMax {
+		n := len(m.deadIndex) - m.cfg.DeadLetterMax
+		discard = slices.Clone(m.deadIndex[:n])
+		m.deadIndex = slices.Delete(m.deadIndex, 0, n)
+	}
+	for _, old := range discard {
+		slog.Warn("Dead-letter store full, discarding oldest", "taskId", old.id)
+		if err := m.dead.Done(old.id); err != nil {
+			slog.Error("Failed to update dead-letter store", "taskId", old.id, "error", err)
+		}
+	}
+	m.deadMu.Unlock()
+
+	m.finish(task)
+}
+
+// DeadLetters returns the tasks that exhausted their attempts, oldest first.
+func (m *Manager) DeadLetters() ([]*Task, error) {
+	return m.dead.Pending()
+}
+
+// ReplayDeadLetter enqueues a dead letter again with a fresh attempt budget.
+// The letter leaves the dead-letter store before it is dispatched, so a
+// concurrent replay of the same ID finds nothing to replay.
+func (m *Manager) ReplayDeadLetter(id string) (*Task, error) {
+	m.deadMu.Lock()
+	task, err := m.findDeadLetter(id)
+	if err != nil {
+		m.deadMu.Unlock()
+		return nil, err
+	}
+
+	svc, err := service.GetService(task.Channel)
+	if err != nil {
+		m.deadMu.Unlock()
+		return nil, err
+	}
+
+	failed := *task
+	task.Attempts = 0
+	task.LastError = ""
+	task.FailedAt = time.Time{}
+	if err := m.store.Put(task); err != nil {
+		m.deadMu.Unlock()
+		return nil, err
+	}
+	if err := m.dead.Done(id); err != nil {
+		slog.Error("Failed to update dead-letter store", "taskId", id, "error", err)
+	}
+	m.unindexDeadLetter(id)
+	m.deadMu.Unlock()
+
+	slog.Info("Replaying dead letter", "taskId", id, "channel", task.Channel, "target", task.Target)
+	if err := m.dispatch(svc, task, m.cfg.BufferSize); err != nil {
+		m.finish(task)
+		m.restoreDeadLetter(&failed)
+		return nil, err
+	}
+	return task, nil
+}
+
+// restoreDeadLetter puts back a dead letter whose replay could not be
+// dispatched.
+func (m *Manager) restoreDeadLetter(task *Task) {
+	m.track(task, TaskFailed, "")
+
+	m.deadMu.Lock()
+	defer m.deadMu.Unlock()
+	if err := m.dead.Put(task); err != nil {
+		slog.Error("Failed to store dead letter", "taskId", task.ID, "error", err)
+		return
+	}
+	m.indexDeadLetter(task)
+}
+
+// PurgeDeadLetter permanently removes a dead letter.
+func (m *Manager) PurgeDeadLetter(id string) error {
+	m.deadMu.Lock()
+	defer m.deadMu.Unlock()
+
+	if _, err := m.findDeadLetter(id); err != nil {
+		return err
+	}
+	m.unindexDeadLetter(id)
+	return m.dead.Done(id)
+}
+
+func (m *Manager) findDeadLetter(id string) (*Task, error) {
+	tasks, err := m.dead.Pending()
+	if err != nil {
+		return nil, err
+	}
+	for _, task := range tasks {
+		if task.ID == id {
+			return task, nil
+		}
+	}
+	return nil, ErrTaskNotFound
+}
@@ -0,0 +1,79 @@
+package queue
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"notify/internal/config"
+	"notify/internal/service"
+)
+
+func TestDeadLetterKeepsNewestWithinLimit(t *testing.T) {
+	m := newManager(config.QueueConfig{DeadLetterMax: 2}, nopStore{}, newMemStore())
+	now := time.Now()
+	// Tasks fail in the order 0, 1, 2 but were created in reverse, so trimming
+	// by CreatedAt would keep the wrong ones.
+	for i := range 3 {
+		m.deadLetter(&Task{
+			ID:        fmt.Sprintf("task_%d", i),
+			CreatedAt: now.Add(-time.Duration(i) * time.Second),
+			LastError: "feishu error: 230002 - Bot is not in the chat",
+		})
+	}
+
+	tasks, err := m.DeadLetters()
+	if err != nil {
+		t.Fatalf("DeadLetters() error = %v", err)
+	}
+	if len(tasks) != 2 || tasks[0].ID != "task_2" || tasks[1].ID != "task_1" {
+		t.Fatalf("DeadLetters() = %#v", tasks)
+	}
+	if tasks[0].FailedAt.IsZero() || tasks[0].LastError == "" {
+		t.Fatalf("dead letter = %#v", tasks[0])
+	}
+
+	status, err := m.TaskStatus("task_2")
+	if err != nil || status.State != TaskFailed {
+		t.Fatalf("TaskStatus() = %#v, %v", status, err)
+	}
+
+	if err := m.PurgeDeadLetter("task_1"); err != nil {
+		t.Fatalf("PurgeDeadLetter() error = %v", err)
+	}
+	if err := m.PurgeDeadLetter("task_1"); !errors.Is(err, ErrTaskNotFound) {
+		t.Fatalf("PurgeDeadLetter() error = %v, want ErrTaskNotFound", err)
+	}
+}
+
+func TestReplayDeadLetterOnce(t *testing.T) {
+	if err := service.Init(&config.Config{Telegram: config.TelegramConfig{BotToken: "test"}}); err != nil {
+		t.Fatal(err)
+	}
+	m := newManager(config.QueueConfig{BufferSize: 10}, nopStore{}, newMemStore())
+	tq := newTestQueue(m, &fakeService{}, 10)
+	m.deadLetter(&Task{ID: "task_1", Channel: service.ChannelTelegram, Target: "-100", Attempts: 3})
+
+	var wg sync.WaitGroup
+	var replayed atomic.Int32
+	for range 5 {
+		wg.Go(func() {
+			if _, err := m.ReplayDeadLetter("task_1"); err == nil {
+				replayed.Add(1)
+			} else if !errors.Is(err, ErrTaskNotFound) {
+				t.Errorf("ReplayDeadLetter() error = %v", err)
+			}
+		})
+	}
+	wg.Wait()
+
+	if replayed.Load() != 1 || tq.len() != 1 {
+		t.Fatalf("replayed = %d, queued = %d; want 1, 1", replayed.Load(), tq.len())
+	}
+	if tasks, _ := m.DeadLetters(); len(tasks) != 0 || len(m.deadIndex) != 0 {
+		t.Fatalf("DeadLetters() = %#v after replay", tasks)
+	}
+}
@@ -0,0 +1,61 @@
+package queue
+
+import (
+	"time"
+)
+
+type dedupEntry struct {
+	taskID    string
+	expiresAt time.Time
+}
+
+// reserveDedupKey claims the task's dedup key. It returns false and the ID of
+// the original task when the key was already used within the dedup window.
+func (m *Manager) reserveDedupKey(task *Task) (string, bool) {
+	if task.DedupKey == "" || m.cfg.DedupWindow <= 0 {
+		return "", true
+	}
+	key := dedupMapKey(task)
+
+	m.dedupMu.Lock()
+	defer m.dedupMu.Unlock()
+
+	now := time.Now()
+	if entry, ok := m.dedup[key]; ok && now.Before(entry.expiresAt) {
+		return entry.taskID, false
+	}
+	m.dedup[key] = dedupEntry{taskID: task.ID, expiresAt: task.CreatedAt.Add(m.cfg.DedupWindow)}
+	return "", true
+}
+
+// releaseDedupKey forgets a key whose task was never accepted so the caller
+// can retry with it.
+func (m *Manager) releaseDedupKey(task *Task) {
+	if task.DedupKey == "" {
+		return
+	}
+	key := dedupMapKey(task)
+
+	m.dedupMu.Lock()
+	defer m.dedupMu.Unlock()
+
+	if entry, ok := m.dedup[key]; ok && entry.taskID == task.ID {
+		delete(m.dedup, key)
+	}
+}
+
+func (m *Manager) pruneDedupKeys(now time.Time) {
+	m.dedupMu.Lock()
+	defer m.dedupMu.Unlock()
+
+	for key, entry := range m.dedup {
+		if !now.Before(entry.expiresAt) {
+			delete(m.dedup, key)
+		}
+	}
+}
+
+// dedupMapKey scopes keys to a target so different callers cannot collide.
+func dedupMapKey(task *Task) string {
+	return queueKey(task.Channel, task.Target) + ":" + task.DedupKey
+}
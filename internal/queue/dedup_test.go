@@ -0,0 +1,37 @@
+package queue
+
+import (
+	"testing"
+	"time"
+
+	"notify/internal/config"
+	"notify/internal/service"
+)
+
+func TestReserveDedupKey(t *testing.T) {
+	m := newManager(config.QueueConfig{DedupWindow: time.Minute}, nopStore{}, newMemStore())
+	now := time.Now()
+	newTask := func(id, target string) *Task {
+		return &Task{ID: id, Channel: service.ChannelFeishu, Target: target, DedupKey: "deploy-42", CreatedAt: now}
+	}
+
+	if _, ok := m.reserveDedupKey(newTask("task_1", "oc_a")); !ok {
+		t.Fatal("first reservation rejected")
+	}
+	if id, ok := m.reserveDedupKey(newTask("task_2", "oc_a")); ok || id != "task_1" {
+		t.Fatalf("duplicate reservation = %q, %v; want task_1, false", id, ok)
+	}
+	if _, ok := m.reserveDedupKey(newTask("task_3", "oc_b")); !ok {
+		t.Fatal("same key for another target rejected")
+	}
+
+	m.releaseDedupKey(newTask("task_1", "oc_a"))
+	if _, ok := m.reserveDedupKey(newTask("task_4", "oc_a")); !ok {
+		t.Fatal("reservation after release rejected")
+	}
+
+	m.pruneDedupKeys(now.Add(2 * time.Minute))
+	if len(m.dedup) != 0 {
+		t.Fatalf("dedup = %#v, want empty after window", m.dedup)
+	}
+}
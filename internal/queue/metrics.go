@@ -0,0 +1,78 @@
+package queue
+
+import (
+	"time"
+
+	"notify/internal/metrics"
+)
+
+var (
+	tasksEnqueued = metrics.NewCounterVec("notify_tasks_enqueued_total",
+		"Tasks accepted into a target queue.", "channel")
+	tasksSent = metrics.NewCounterVec("notify_tasks_sent_total",
+		"Tasks delivered to the platform.", "channel")
+	tasksRetried = metrics.NewCounterVec("notify_tasks_retried_total",
+		"Failed send attempts that were retried.", "channel")
+	tasksDropped = metrics.NewCounterVec("notify_tasks_dropped_total",
+		"Tasks discarded because their queue was full, by overflow outcome.", "channel", "reason")
+	tasksFailed = metrics.NewCounterVec("notify_tasks_failed_total",
+		"Tasks moved to the dead-letter list.", "channel")
+
+	sendDuration = metrics.NewHistogramVec("notify_send_duration_seconds",
+		"Latency of a single send request to the platform.", metrics.DefBuckets, "channel")
+	queueWait = metrics.NewHistogramVec("notify_queue_wait_seconds",
+		"Time from when a task was due until its first send attempt.",
+		[]float64{.1, .5, 1, 5, 10, 30, 60, 300, 900, 3600}, "channel")
+	sendAttempts = metrics.NewHistogramVec("notify_send_attempts",
+		"Send attempts used by each finished task.", []float64{1, 2, 3, 5, 10}, "channel")
+
+	_ = metrics.NewGaugeFunc("notify_target_queues",
+		"Active target queues.", collectQueues(func(*targetQueue) float64 { return 1 }), "channel")
+	_ = metrics.NewGaugeFunc("notify_queue_depth",
+		"Tasks buffered in target queues.", collectQueues(func(tq *targetQueue) float64 { return float64(tq.len()) }), "channel")
+)
+
+// collectQueues sums value over the active queues of each channel.
+func collectQueues(value func(*targetQueue) float64) func(emit func(float64, ...string)) {
+	return func(emit func(float64, ...string)) {
+		m := GetManager()
+		if m == nil {
+			return
+		}
+
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		for _, tq := range m.queues {
+			emit(value(tq), string(tq.channel))
+		}
+	}
+}
+
+// observe updates the task counters for a state change.
+func observe(task *Task, state TaskState) {
+	channel := string(task.Channel)
+	switch state {
+	case TaskQueued:
+		tasksEnqueued.Inc(channel)
+	case TaskRetrying:
+		tasksRetried.Inc(channel)
+	case TaskSent:
+		tasksSent.Inc(channel)
+		sendAttempts.Observe(float64(task.Attempts), channel)
+	case TaskFailed:
+		tasksFailed.Inc(channel)
+		sendAttempts.Observe(float64(task.Attempts), channel)
+	case TaskDropped, TaskCoalesced:
+		tasksDropped.Inc(channel, string(state))
+	}
+}
+
+// observeWait records how long a task waited in its queue before the first
+// attempt. Scheduled tasks are due at SendAt rather than when submitted.
+func observeWait(task *Task, now time.Time) {
+	due := task.CreatedAt
+	if task.SendAt.After(due) {
+		due = task.SendAt
+	}
+	queueWait.Observe(now.Sub(due).Seconds(), string(task.Channel))
+}
@@ -0,0 +1,96 @@
+package queue
+
+import (
+	"fmt"
+)
+
+type Priority string
+
+const (
+	PriorityHigh   Priority = "high"
+	PriorityNormal Priority = "normal"
+	PriorityLow    Priority = "low"
+)
+
+// priorities lists the levels from most to least urgent; the index is the
+// level's slot in targetQueue.tasks.
+var priorities = [...]Priority{PriorityHigh, PriorityNormal, PriorityLow}
+
+// starvationLimit is how many times a waiting lower-priority task may be
+// passed over before it is served ahead of higher-priority ones.
+const starvationLimit = 10
+
+func ParsePriority(s string) (Priority, error) {
+	switch p := Priority(s); p {
+	case "":
+		return PriorityNormal, nil
+	case PriorityHigh, PriorityNormal, PriorityLow:
+		return p, nil
+	default:
+		return "", fmt.Errorf("invalid priority: %s", s)
+	}
+}
+
+func (p Priority) level() int {
+	for i, priority := range priorities {
+		if priority == p {
+			return i
+		}
+	}
+	// Tasks persisted before priorities existed have none.
+	return 1
+}
+
+// len returns the number of queued tasks across all priorities.
+func (tq *targetQueue) len() int {
+	n := 0
+	for _, tasks := range tq.tasks {
+		n += len(tasks)
+	}
+	return n
+}
+
+// next takes the most urgent queued task without blocking, or returns nil.
+// Only the worker calls next, so starved needs no locking.
+func (tq *targetQueue) next() *Task {
+	// A lower priority that has been passed over too often goes first.
+	for level := 1; level < len(priorities); level++ {
+		if tq.starved[level] < starvationLimit {
+			continue
+		}
+		select {
+		case task := <-tq.tasks[level]:
+			tq.starved[level] = 0
+			return task
+		default:
+			tq.starved[level] = 0
+		}
+	}
+
+	for level := range priorities {
+		select {
+		case task := <-tq.tasks[level]:
+			for lower := level + 1; lower < len(priorities); lower++ {
+				if len(tq.tasks[lower]) > 0 {
+					tq.starved[lower]++
+				}
+			}
+			return task
+		default:
+		}
+	}
+	return nil
+}
+
+// takeOldest removes the oldest task of the least urgent non-empty priority.
+// Caller must hold Manager.mu.
+func (tq *targetQueue) takeOldest() *Task {
+	for level := len(priorities) - 1; level >= 0; level-- {
+		select {
+		case task := <-tq.tasks[level]:
+			return task
+		default:
+		}
+	}
+	return nil
+}
@@ -4,6 +4,10 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"math"
+	"math/rand/v2"
+	"strconv"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -15,93 +19,404 @@ import (
 )
 
 type Task struct {
-	ID        string
-	Channel   service.Channel
-	Target    string
-	Message   any
-	Attempts  int
-	CreatedAt time.Time
-	LastError string
+	ID        string          `json:"id"`
+	Channel   service.Channel `json:"channel"`
+	Target    string          `json:"target"`
+	Message   any             `json:"message"`
+	Attempts  int             `json:"attempts"`
+	CreatedAt time.Time       `json:"createdAt"`
+	LastError string          `json:"lastError,omitempty"`
+	FailedAt  time.Time       `json:"failedAt,omitzero"`
+	DedupKey  string          `json:"dedupKey,omitempty"`
+	SendAt    time.Time       `json:"sendAt,omitzero"`
+	Priority  Priority        `json:"priority,omitempty"`
+	// RateLimited counts rate-limited sends, which do not use up Attempts.
+	RateLimited int `json:"rateLimited,omitempty"`
+}
+
+// EnqueueOptions are optional per-task settings supplied by the caller.
+type EnqueueOptions struct {
+	// DedupKey makes repeated submissions to the same target within the dedup
+	// window return the original task instead of enqueueing a duplicate.
+	DedupKey string
+	// SendAt delays delivery until the given time; zero or past times send immediately.
+	SendAt time.Time
+	// Priority defaults to PriorityNormal.
+	Priority Priority
 }
 
 type targetQueue struct {
 	key     string
-	tasks   chan *Task
-	limiter *rate.Limiter
-	svc     service.NotifyService
+	channel service.Channel
+	target  string
+	// tasks holds one channel per priority level, each sized to the queue
+	// capacity; the total across levels is bounded by capacity.
+	tasks    [len(priorities)]chan *Task
+	capacity int
+	starved  [len(priorities)]int
+	limiter  *rate.Limiter
+	// globalLimiter and channelBreaker are shared by all targets of the
+	// channel; breaker outlives the queue so an idle target stays open.
+	globalLimiter  *rate.Limiter
+	breaker        *breaker
+	channelBreaker *breaker
+	svc            service.NotifyService
+	// coalesced counts tasks folded into the next summary message; guarded by Manager.mu.
+	coalesced int
+}
+
+// newTargetQueue creates a queue with limiters and breakers for its channel.
+// Caller must hold m.mu.
+func (m *Manager) newTargetQueue(channel service.Channel, target string, capacity int, svc service.NotifyService) *targetQueue {
+	key := queueKey(channel, target)
+	limits := m.cfg.LimitsFor(string(channel))
+	tq := &targetQueue{
+		key:            key,
+		channel:        channel,
+		target:         target,
+		capacity:       capacity,
+		limiter:        newLimiter(limits.Target),
+		globalLimiter:  m.globalLimiter(channel, limits.Global),
+		breaker:        m.targetBreaker(key),
+		channelBreaker: m.channelBreaker(channel),
+		svc:            svc,
+	}
+	for level := range tq.tasks {
+		tq.tasks[level] = make(chan *Task, capacity)
+	}
+	return tq
+}
+
+// QueueFullError is returned when a target queue has no room and the overflow
+// policy rejects new tasks.
+type QueueFullError struct {
+	Key        string
+	RetryAfter time.Duration
+}
+
+func (e *QueueFullError) Error() string {
+	return fmt.Sprintf("queue full for %s", e.Key)
 }
 
 type Manager struct {
-	queues  map[string]*targetQueue
-	mu      sync.Mutex
-	wg      sync.WaitGroup
-	ctx     context.Context
-	cancel  context.CancelFunc
-	cfg     config.QueueConfig
-	taskSeq atomic.Uint64
+	queues   map[string]*targetQueue
+	global   map[service.Channel]*rate.Limiter
+	breakers map[service.Channel]*breaker
+	// targetBreakers are keyed like queues and kept after a queue closes.
+	targetBreakers map[string]*breaker
+	mu             sync.Mutex
+	wg             sync.WaitGroup
+	ctx            context.Context
+	cancel         context.CancelFunc
+	cfg            config.QueueConfig
+	store          taskStore
+	dead           taskStore
+	// deadIndex lists dead letters by FailedAt; guarded by deadMu, which also
+	// serializes changes to the dead-letter store.
+	deadIndex  []deadEntry
+	deadMu     sync.Mutex
+	statuses   map[string]*TaskStatus
+	waiters    map[string]*waiter
+	statusesMu sync.RWMutex
+	dedup      map[string]dedupEntry
+	dedupMu    sync.Mutex
+	// scheduled holds tasks with a future SendAt; see runScheduler.
+	scheduled    taskHeap
+	scheduleMu   sync.Mutex
+	scheduleWake chan struct{}
+	taskSeq      atomic.Uint64
 }
 
 var manager *Manager
 
-func Init(cfg config.QueueConfig) {
+func Init(cfg config.QueueConfig) error {
+	var store taskStore = nopStore{}
+	var dead taskStore = newMemStore()
+	if cfg.DataDir != "" {
+		fs, err := openFileStore(cfg.DataDir, taskLogName)
+		if err != nil {
+			return fmt.Errorf("open task store: %w", err)
+		}
+		store = fs
+
+		ds, err := openFileStore(cfg.DataDir, deadLetterLogName)
+		if err != nil {
+			return fmt.Errorf("open dead-letter store: %w", err)
+		}
+		dead = ds
+	}
+
+	manager = newManager(cfg, store, dead)
+	go manager.prune()
+	manager.wg.Add(1)
+	go manager.runScheduler()
+	return manager.restore()
+}
+
+func newManager(cfg config.QueueConfig, store, dead taskStore) *Manager {
 	ctx, cancel := context.WithCancel(context.Background())
-	manager = &Manager{
-		queues: make(map[string]*targetQueue),
-		ctx:    ctx,
-		cancel: cancel,
-		cfg:    cfg,
+	m := &Manager{
+		queues:         make(map[string]*targetQueue),
+		global:         make(map[service.Channel]*rate.Limiter),
+		breakers:       make(map[service.Channel]*breaker),
+		targetBreakers: make(map[string]*breaker),
+		statuses:       make(map[string]*TaskStatus),
+		waiters:        make(map[string]*waiter),
+		dedup:          make(map[string]dedupEntry),
+		scheduleWake:   make(chan struct{}, 1),
+		ctx:            ctx,
+		cancel:         cancel,
+		cfg:            cfg,
+		store:          store,
+		dead:           dead,
 	}
+	m.loadDeadLetters()
+	return m
 }
 
 func GetManager() *Manager {
 	return manager
 }
 
-func (m *Manager) Enqueue(channel service.Channel, target string, message any) {
-	seq := m.taskSeq.Add(1)
-	taskID := fmt.Sprintf("task_%d_%d", time.Now().UnixNano(), seq)
+// Enqueue persists the message, hands it to the worker for its target and
+// returns the task ID.
+func (m *Manager) Enqueue(channel service.Channel, target string, message any, opts EnqueueOptions) (string, error) {
+	svc, err := service.GetService(channel)
+	if err != nil {
+		return "", err
+	}
+
+	task := m.newTask(channel, target, message)
+	task.DedupKey = opts.DedupKey
+	task.Priority = opts.Priority
+	if task.Priority == "" {
+		task.Priority = PriorityNormal
+	}
+	if opts.SendAt.After(task.CreatedAt) {
+		task.SendAt = opts.SendAt
+	}
+	if originalID, ok := m.reserveDedupKey(task); !ok {
+		slog.Info("Duplicate task ignored", "taskId", originalID, "channel", channel, "target", target)
+		return originalID, nil
+	}
 
-	task := &Task{
-		ID:        taskID,
+	if err := m.store.Put(task); err != nil {
+		m.releaseDedupKey(task)
+		return "", fmt.Errorf("persist task: %w", err)
+	}
+
+	if !task.SendAt.IsZero() {
+		m.schedule(task)
+		return task.ID, nil
+	}
+
+	if err := m.dispatch(svc, task, m.cfg.BufferSize); err != nil {
+		m.releaseDedupKey(task)
+		m.finish(task)
+		return "", err
+	}
+	return task.ID, nil
+}
+
+func (m *Manager) newTask(channel service.Channel, target string, message any) *Task {
+	seq := m.taskSeq.Add(1)
+	return &Task{
+		ID:        fmt.Sprintf("task_%d_%d", time.Now().UnixNano(), seq),
 		Channel:   channel,
 		Target:    target,
 		Message:   message,
 		Attempts:  0,
 		CreatedAt: time.Now(),
 	}
+}
 
-	key := fmt.Sprintf("%s:%s", channel, target)
+// restore replays tasks left in the store by a previous run.
+func (m *Manager) restore() error {
+	tasks, err := m.store.Pending()
+	if err != nil {
+		return fmt.Errorf("load pending tasks: %w", err)
+	}
+	if len(tasks) == 0 {
+		return nil
+	}
 
-	m.mu.Lock()
-	tq, exists := m.queues[key]
-	if !exists {
-		svc, err := service.GetService(channel)
-		if err != nil {
-			m.mu.Unlock()
-			slog.Error("Failed to get service for queue", "channel", channel, "error", err)
-			return
+	// Size replayed queues so that a backlog larger than BufferSize is not dropped.
+	now := time.Now()
+	counts := make(map[string]int)
+	for _, task := range tasks {
+		if !task.SendAt.After(now) {
+			counts[queueKey(task.Channel, task.Target)]++
 		}
+	}
 
-		tq = &targetQueue{
-			key:     key,
-			tasks:   make(chan *Task, m.cfg.BufferSize),
-			limiter: rate.NewLimiter(rate.Limit(m.cfg.RatePerSecond), 1),
-			svc:     svc,
+	for _, task := range tasks {
+		m.reserveDedupKey(task)
+		if task.SendAt.After(now) {
+			m.schedule(task)
+			continue
+		}
+		svc, err := service.GetService(task.Channel)
+		if err != nil {
+			task.LastError = err.Error()
+			m.deadLetter(task)
+			continue
+		}
+		if err := m.dispatch(svc, task, max(m.cfg.BufferSize, counts[queueKey(task.Channel, task.Target)])); err != nil {
+			slog.Error("Failed to restore task", "taskId", task.ID, "error", err)
 		}
+	}
+	slog.Info("Restored pending tasks", "count", len(tasks))
+	return nil
+}
+
+// dispatch hands a task to the worker for its target, applying the overflow
+// policy when the target queue is full.
+func (m *Manager) dispatch(svc service.NotifyService, task *Task, capacity int) error {
+	key := queueKey(task.Channel, task.Target)
+
+	m.mu.Lock()
+	tq, exists := m.queues[key]
+	if !exists {
+		tq = m.newTargetQueue(task.Channel, task.Target, capacity, svc)
 		m.queues[key] = tq
 
 		m.wg.Add(1)
 		go m.runWorker(tq)
 	}
 
-	// Send to channel while holding the lock to prevent race with worker shutdown
-	select {
-	case tq.tasks <- task:
-		slog.Info("Task enqueued", "taskId", taskID, "channel", channel, "target", target)
-	default:
-		slog.Warn("Queue full, task dropped", "taskId", taskID, "channel", channel, "target", target)
+	// Only dispatch sends on tq.tasks and it holds m.mu, so the length check
+	// below cannot be invalidated by another sender.
+	var dropped *Task
+	if tq.len() >= tq.capacity {
+		switch m.cfg.OverflowPolicy {
+		case config.OverflowDropOldest:
+			// nil if the worker took one in the meantime.
+			dropped = tq.takeOldest()
+		case config.OverflowCoalesce:
+			tq.coalesced++
+			m.mu.Unlock()
+			slog.Warn("Queue full, task coalesced", "taskId", task.ID, "channel", task.Channel, "target", task.Target)
+			m.track(task, TaskCoalesced, "")
+			m.finish(task)
+			return nil
+		default:
+			m.mu.Unlock()
+			slog.Warn("Queue full, task rejected", "taskId", task.ID, "channel", task.Channel, "target", task.Target)
+			return &QueueFullError{Key: key, RetryAfter: m.retryAfter(task.Channel)}
+		}
 	}
+
+	// Track before the worker can pick the task up so states never go backwards.
+	m.track(task, TaskQueued, "")
+	tq.tasks[task.Priority.level()] <- task
 	m.mu.Unlock()
+	slog.Info("Task enqueued", "taskId", task.ID, "channel", task.Channel, "target", task.Target)
+
+	if dropped != nil {
+		slog.Warn("Queue full, oldest task dropped", "taskId", dropped.ID, "channel", dropped.Channel, "target", dropped.Target)
+		m.track(dropped, TaskDropped, "")
+		m.finish(dropped)
+	}
+	return nil
+}
+
+// flushCoalesced enqueues a summary of coalesced tasks once the target queue
+// has drained, and reports whether it did.
+func (m *Manager) flushCoalesced(tq *targetQueue) bool {
+	m.mu.Lock()
+	count := tq.coalesced
+	if count == 0 || tq.len() > 0 {
+		m.mu.Unlock()
+		return false
+	}
+	tq.coalesced = 0
+	m.mu.Unlock()
+
+	message := tq.svc.BuildMessage(service.MessageParams{
+		Title:   m.cfg.CoalesceTitle,
+		Color:   service.ColorOrange,
+		Content: strings.ReplaceAll(m.cfg.CoalesceMessage, "{count}", strconv.Itoa(count)),
+	})
+	task := m.newTask(tq.channel, tq.target, message)
+	task.Priority = PriorityHigh
+	if err := m.store.Put(task); err != nil {
+		slog.Error("Failed to persist coalesced summary", "key", tq.key, "error", err)
+	}
+	if err := m.dispatch(tq.svc, task, m.cfg.BufferSize); err != nil {
+		m.finish(task)
+		slog.Error("Failed to enqueue coalesced summary", "key", tq.key, "error", err)
+		return false
+	}
+	return true
+}
+
+// globalLimiter returns the limiter shared by all targets of a channel.
+// Caller must hold m.mu.
+func (m *Manager) globalLimiter(channel service.Channel, limit config.RateLimit) *rate.Limiter {
+	limiter, ok := m.global[channel]
+	if !ok {
+		limiter = newLimiter(limit)
+		m.global[channel] = limiter
+	}
+	return limiter
+}
+
+// channelBreaker returns the breaker shared by all targets of a channel.
+// Caller must hold m.mu.
+func (m *Manager) channelBreaker(channel service.Channel) *breaker {
+	b, ok := m.breakers[channel]
+	if !ok {
+		b = newBreaker(string(channel), m.cfg.BreakerThreshold, m.cfg.BreakerCooldown)
+		m.breakers[channel] = b
+	}
+	return b
+}
+
+func newLimiter(limit config.RateLimit) *rate.Limiter {
+	if limit.Rate <= 0 {
+		return rate.NewLimiter(rate.Inf, 0)
+	}
+	return rate.NewLimiter(rate.Limit(limit.Rate), max(1, limit.Burst))
+}
+
+// retryAfter estimates how long a client should wait for a slot to free up in
+// a target queue of the channel.
+func (m *Manager) retryAfter(channel service.Channel) time.Duration {
+	targetRate := m.cfg.LimitsFor(string(channel)).Target.Rate
+	if targetRate <= 0 {
+		return time.Second
+	}
+	return max(time.Second, time.Duration(math.Ceil(1/targetRate))*time.Second)
+}
+
+// finish removes a task that will not be attempted again from the store.
+func (m *Manager) finish(task *Task) {
+	if err := m.store.Done(task.ID); err != nil {
+		slog.Error("Failed to update task store", "taskId", task.ID, "error", err)
+	}
+}
+
+// pruneInterval is how often expired statuses and dedup keys are removed.
+const pruneInterval = time.Minute
+
+func (m *Manager) prune() {
+	ticker := time.NewTicker(pruneInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-m.ctx.Done():
+			return
+		case now := <-ticker.C:
+			m.pruneStatuses(now)
+			m.pruneDedupKeys(now)
+			m.pruneBreakers()
+		}
+	}
+}
+
+func queueKey(channel service.Channel, target string) string {
+	return fmt.Sprintf("%s:%s", channel, target)
 }
 
 func (m *Manager) runWorker(tq *targetQueue) {
@@ -111,27 +426,38 @@ func (m *Manager) runWorker(tq *targetQueue) {
 	defer idleTimer.Stop()
 
 	for {
-		select {
-		case <-m.ctx.Done():
+		if m.ctx.Err() != nil {
 			m.drainQueue(tq)
 			return
+		}
 
-		case task := <-tq.tasks:
-			idleTimer.Reset(m.cfg.IdleTimeout)
-			m.processTask(m.ctx, tq, task)
-
-		case <-idleTimer.C:
-			m.mu.Lock()
-			// Check if queue is empty while holding lock
-			if len(tq.tasks) == 0 {
-				delete(m.queues, tq.key)
+		task := tq.next()
+		if task == nil {
+			// Nothing queued: block until any priority receives a task.
+			select {
+			case <-m.ctx.Done():
+				continue
+			case task = <-tq.tasks[0]:
+			case task = <-tq.tasks[1]:
+			case task = <-tq.tasks[2]:
+			case <-idleTimer.C:
+				m.mu.Lock()
+				// Check if queue is empty while holding lock
+				if tq.len() == 0 {
+					delete(m.queues, tq.key)
+					m.mu.Unlock()
+					slog.Info("Queue idle, closing", "key", tq.key)
+					return
+				}
 				m.mu.Unlock()
-				slog.Info("Queue idle, closing", "key", tq.key)
-				return
+				idleTimer.Reset(m.cfg.IdleTimeout)
+				continue
 			}
-			m.mu.Unlock()
-			idleTimer.Reset(m.cfg.IdleTimeout)
 		}
+
+		idleTimer.Reset(m.cfg.IdleTimeout)
+		m.processTask(m.ctx, tq, task)
+		m.flushCoalesced(tq)
 	}
 }
 
@@ -139,59 +465,192 @@ func (m *Manager) processTask(ctx context.Context, tq *targetQueue, task *Task)
 	if err := tq.limiter.Wait(ctx); err != nil {
 		return
 	}
+	if err := tq.globalLimiter.Wait(ctx); err != nil {
+		return
+	}
 
 	for task.Attempts < m.cfg.MaxAttempts {
+		if !m.awaitBreakers(ctx, tq, task) {
+			return
+		}
+
+		if task.Attempts == 0 && task.RateLimited == 0 {
+			observeWait(task, time.Now())
+		}
 		task.Attempts++
-		_, err := tq.svc.SendRawMessage(task.Target, task.Message)
+		m.track(task, TaskSending, "")
+		result, err := m.send(tq, task)
+		tq.record(err)
 		if err == nil {
 			slog.Info("Message sent", "attempt", task.Attempts)
+			m.track(task, TaskSent, result.MessageID)
+			m.finish(task)
 			return
 		}
 		task.LastError = err.Error()
-		slog.Warn("Send failed", "taskId", task.ID, "attempt", task.Attempts, "error", err)
+		kind, retryAfter := service.ClassifyError(err)
+		slog.Warn("Send failed", "taskId", task.ID, "attempt", task.Attempts, "kind", kind, "error", err)
 
-		if task.Attempts < m.cfg.MaxAttempts {
-			// Exponential backoff: delay = RetryDelay * 2^(attempts-1)
-			backoffFactor := 1 << (task.Attempts - 1)
-			delay := m.cfg.RetryDelay * time.Duration(backoffFactor)
+		if kind == service.ErrorPermanent {
+			slog.Error("Send failed permanently", "taskId", task.ID, "attempts", task.Attempts, "lastError", task.LastError)
+			m.deadLetter(task)
+			return
+		}
+		if kind == service.ErrorRateLimited {
+			if m.countRateLimited(task) {
+				m.retryLater(task, retryAfter)
+			}
+			return
+		}
 
+		if task.Attempts < m.cfg.MaxAttempts {
+			m.track(task, TaskRetrying, "")
 			select {
 			case <-ctx.Done():
 				return
-			case <-time.After(delay):
+			case <-time.After(m.backoff(task.Attempts)):
 			}
 		}
 	}
 	slog.Error("Send failed after retries", "taskId", task.ID, "attempts", m.cfg.MaxAttempts, "lastError", task.LastError)
+	m.deadLetter(task)
 }
 
+// send makes a single delivery attempt and records its latency. Services
+// that support transaction IDs get the task ID, which stays the same across
+// retries and restarts.
+func (m *Manager) send(tq *targetQueue, task *Task) (*service.SendResult, error) {
+	start := time.Now()
+	var result *service.SendResult
+	var err error
+	if txn, ok := tq.svc.(service.TransactionalSender); ok {
+		result, err = txn.SendRawMessageTxn(task.Target, task.Message, task.ID)
+	} else {
+		result, err = tq.svc.SendRawMessage(task.Target, task.Message)
+	}
+	sendDuration.Observe(time.Since(start).Seconds(), string(tq.channel))
+	return result, err
+}
+
+// countRateLimited gives back the attempt a rate-limited send used and
+// reports whether the task may be retried. A task rate limited more than
+// MaxRateLimited times is dead-lettered with the last error.
+func (m *Manager) countRateLimited(task *Task) bool {
+	task.Attempts--
+	task.RateLimited++
+	if m.cfg.MaxRateLimited > 0 && task.RateLimited > m.cfg.MaxRateLimited {
+		slog.Error("Send rate limited too many times", "taskId", task.ID, "rateLimited", task.RateLimited, "lastError", task.LastError)
+		m.deadLetter(task)
+		return false
+	}
+	return true
+}
+
+// retryLater hands a rate-limited task to the scheduler rather than holding
+// the worker until the platform's retry hint has passed. Retrying before the
+// hint only extends some platforms' blocks, so it is used as is; without one
+// the task backs off up to maxRetryDelay.
+func (m *Manager) retryLater(task *Task, delay time.Duration) {
+	if delay <= 0 {
+		delay = min(m.backoff(task.RateLimited), m.maxRetryDelay())
+	}
+	task.SendAt = time.Now().Add(delay)
+	if err := m.store.Put(task); err != nil {
+		slog.Error("Failed to persist rate-limited task", "taskId", task.ID, "error", err)
+	}
+
+	// Track first so the scheduler cannot move the task on before it is retrying.
+	m.track(task, TaskRetrying, "")
+	slog.Info("Rate limited, task rescheduled", "taskId", task.ID, "retryIn", delay)
+	m.pushScheduled(task)
+}
+
+// maxBackoffShift bounds the backoff exponent so that a large attempt count
+// cannot overflow the delay.
+const maxBackoffShift = 16
+
+// maxRetryDelay is the longest backoff the attempt budget allows,
+// RetryDelay * 2^MaxAttempts.
+func (m *Manager) maxRetryDelay() time.Duration {
+	return m.cfg.RetryDelay << min(max(0, m.cfg.MaxAttempts), maxBackoffShift)
+}
+
+// backoff returns the delay before the next attempt: RetryDelay * 2^(attempts-1),
+// randomized between half and the full value so that targets failing together
+// do not retry in lockstep.
+func (m *Manager) backoff(attempts int) time.Duration {
+	delay := m.cfg.RetryDelay << min(max(0, attempts-1), maxBackoffShift)
+	if delay <= 1 {
+		return delay
+	}
+	return delay/2 + rand.N(delay/2)
+}
+
+// drainQueue sends what is left in tq during shutdown, including the summary
+// of any coalesced tasks, before the store is closed.
 func (m *Manager) drainQueue(tq *targetQueue) {
-	// Skip rate limiter during shutdown drain — send remaining tasks as fast as possible.
 	for {
-		select {
-		case task := <-tq.tasks:
-			for task.Attempts < m.cfg.MaxAttempts {
-				task.Attempts++
-				_, err := tq.svc.SendRawMessage(task.Target, task.Message)
-				if err == nil {
-					slog.Info("Message sent during drain", "attempt", task.Attempts)
-					break
-				}
-				task.LastError = err.Error()
-				slog.Warn("Send failed during drain", "taskId", task.ID, "attempt", task.Attempts, "error", err)
+		task := tq.next()
+		if task == nil {
+			if !m.flushCoalesced(tq) {
+				return
 			}
-			if task.LastError != "" && task.Attempts >= m.cfg.MaxAttempts {
-				slog.Error("Send failed after retries during drain", "taskId", task.ID, "attempts", task.Attempts, "lastError", task.LastError)
+			continue
+		}
+		m.drainTask(tq, task)
+	}
+}
+
+func (m *Manager) drainTask(tq *targetQueue, task *Task) {
+	// Skip rate limiter during shutdown drain — send remaining tasks as fast as possible.
+	if ok, _ := tq.allow(time.Now()); !ok {
+		// Leave the task in the store for the next start instead of
+		// spending the shutdown budget on a destination that is down.
+		slog.Warn("Circuit open, task left for restart", "taskId", task.ID, "key", tq.key)
+		return
+	}
+	for task.Attempts < m.cfg.MaxAttempts {
+		task.Attempts++
+		m.track(task, TaskSending, "")
+		result, err := m.send(tq, task)
+		tq.record(err)
+		if err == nil {
+			slog.Info("Message sent during drain", "attempt", task.Attempts)
+			m.track(task, TaskSent, result.MessageID)
+			m.finish(task)
+			return
+		}
+		task.LastError = err.Error()
+		slog.Warn("Send failed during drain", "taskId", task.ID, "attempt", task.Attempts, "error", err)
+		kind, _ := service.ClassifyError(err)
+		if kind == service.ErrorRateLimited {
+			// Retrying now would only extend the block; like an open
+			// circuit, leave the task in the store for the next start.
+			if m.countRateLimited(task) {
+				if err := m.store.Put(task); err != nil {
+					slog.Error("Failed to persist rate-limited task", "taskId", task.ID, "error", err)
+				}
+				slog.Warn("Rate limited, task left for restart", "taskId", task.ID, "key", tq.key)
 			}
-		default:
 			return
 		}
+		if kind == service.ErrorPermanent {
+			break
+		}
 	}
+	slog.Error("Send failed after retries during drain", "taskId", task.ID, "attempts", task.Attempts, "lastError", task.LastError)
+	m.deadLetter(task)
 }
 
 func (m *Manager) Shutdown() {
 	slog.Info("Shutting down queue manager...")
 	m.cancel()
 	m.wg.Wait()
+	if err := m.store.Close(); err != nil {
+		slog.Error("Failed to close task store", "error", err)
+	}
+	if err := m.dead.Close(); err != nil {
+		slog.Error("Failed to close dead-letter store", "error", err)
+	}
 	slog.Info("Queue manager shutdown complete")
 }
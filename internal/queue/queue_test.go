@@ -0,0 +1,368 @@
+package queue
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"notify/internal/config"
+	"notify/internal/service"
+)
+
+type fakeService struct {
+	sent []any
+	errs []error
+}
+
+func (s *fakeService) Channel() service.Channel { return service.ChannelTelegram }
+
+func (s *fakeService) SendMessage(target string, params service.MessageParams) (*service.SendResult, error) {
+	return s.SendRawMessage(target, s.BuildMessage(params))
+}
+
+// SendRawMessage fails with the queued errors in order, then succeeds.
+func (s *fakeService) SendRawMessage(target string, message any) (*service.SendResult, error) {
+	if len(s.errs) > 0 {
+		err := s.errs[0]
+		s.errs = s.errs[1:]
+		return nil, err
+	}
+	s.sent = append(s.sent, message)
+	return &service.SendResult{Success: true}, nil
+}
+
+func (s *fakeService) BuildMessage(params service.MessageParams) any {
+	return map[string]any{"text": params.Title + ": " + params.Content}
+}
+
+// newTestQueue registers a target queue without a worker so tests can inspect
+// what dispatch leaves in it.
+func newTestQueue(m *Manager, svc service.NotifyService, capacity int) *targetQueue {
+	tq := m.newTargetQueue(service.ChannelTelegram, "-100", capacity, svc)
+	m.queues[tq.key] = tq
+	return tq
+}
+
+func TestEnqueueAppliesGlobalAndTargetLimits(t *testing.T) {
+	var mu sync.Mutex
+	var topics []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		topics = append(topics, r.URL.Path)
+		mu.Unlock()
+		_, _ = w.Write([]byte(`{"id":"msg"}`))
+	}))
+	defer srv.Close()
+	delivered := func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return slices.Clone(topics)
+	}
+
+	t.Setenv("APP_NTFY_URL", srv.URL)
+	t.Setenv("QUEUE_RATE_LIMIT", "0.5")
+	// One message per channel until the test ends; targets stay unlimited.
+	t.Setenv("QUEUE_NTFY_GLOBAL_RATE", "0.0001")
+	t.Setenv("QUEUE_NTFY_GLOBAL_BURST", "1")
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := service.Init(cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	// QUEUE_RATE_LIMIT only replaces the target rate of the legacy channels.
+	if got := cfg.Queue.LimitsFor("telegram").Target; got != (config.RateLimit{Rate: 0.5, Burst: 1}) {
+		t.Fatalf("telegram target = %+v", got)
+	}
+	if got := cfg.Queue.LimitsFor("ntfy").Target; got.Rate != 0 {
+		t.Fatalf("ntfy target = %+v, want unlimited", got)
+	}
+
+	m := newManager(cfg.Queue, nopStore{}, newMemStore())
+	defer m.Shutdown()
+	for _, target := range []string{"alerts", "builds"} {
+		if _, err := m.Enqueue(service.ChannelNtfy, target, map[string]any{"message": target}, EnqueueOptions{}); err != nil {
+			t.Fatalf("Enqueue(%s) error = %v", target, err)
+		}
+	}
+
+	m.mu.Lock()
+	alerts, builds := m.queues["ntfy:alerts"], m.queues["ntfy:builds"]
+	m.mu.Unlock()
+	if alerts == nil || builds == nil || alerts.globalLimiter != builds.globalLimiter || alerts.limiter == builds.limiter {
+		t.Fatal("targets do not share the channel limiter")
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for len(delivered()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if got := delivered(); len(got) != 1 {
+		t.Fatalf("delivered = %v, want one message past the channel limit", got)
+	}
+}
+
+func TestDispatchOverflowPolicies(t *testing.T) {
+	tests := []struct {
+		policy  string
+		wantErr bool
+		wantIDs []string
+		state   TaskState
+	}{
+		{policy: config.OverflowReject, wantErr: true, wantIDs: []string{"task_1", "task_2"}},
+		{policy: config.OverflowDropOldest, wantIDs: []string{"task_2", "task_3"}, state: TaskDropped},
+		{policy: config.OverflowCoalesce, wantIDs: []string{"task_1", "task_2"}, state: TaskCoalesced},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			m := newManager(config.QueueConfig{OverflowPolicy: tt.policy, RatePerSecond: 0.5}, nopStore{}, newMemStore())
+			svc := &fakeService{}
+			tq := newTestQueue(m, svc, 2)
+
+			var err error
+			for _, id := range []string{"task_1", "task_2", "task_3"} {
+				err = m.dispatch(svc, &Task{ID: id, Channel: service.ChannelTelegram, Target: "-100", CreatedAt: time.Now()}, 2)
+			}
+
+			var fullErr *QueueFullError
+			if tt.wantErr != errors.As(err, &fullErr) {
+				t.Fatalf("dispatch() error = %v", err)
+			}
+			if tt.wantErr && fullErr.RetryAfter != 2*time.Second {
+				t.Fatalf("RetryAfter = %v, want 2s", fullErr.RetryAfter)
+			}
+
+			var ids []string
+			for task := tq.next(); task != nil; task = tq.next() {
+				ids = append(ids, task.ID)
+			}
+			if len(ids) != len(tt.wantIDs) || ids[0] != tt.wantIDs[0] || ids[1] != tt.wantIDs[1] {
+				t.Fatalf("queued = %v, want %v", ids, tt.wantIDs)
+			}
+
+			if tt.state != "" {
+				lost := "task_1"
+				if tt.policy == config.OverflowCoalesce {
+					lost = "task_3"
+				}
+				status, err := m.TaskStatus(lost)
+				if err != nil || status.State != tt.state {
+					t.Fatalf("TaskStatus(%s) = %#v, %v", lost, status, err)
+				}
+			}
+		})
+	}
+}
+
+func TestFlushCoalescedSendsSummaryAfterDrain(t *testing.T) {
+	m := newManager(config.QueueConfig{
+		OverflowPolicy:  config.OverflowCoalesce,
+		CoalesceTitle:   "Notifications suppressed",
+		CoalesceMessage: "{count} messages to this chat were not delivered because its queue was full.",
+	}, nopStore{}, newMemStore())
+	svc := &fakeService{}
+	tq := newTestQueue(m, svc, 1)
+	tq.coalesced = 3
+
+	m.flushCoalesced(tq)
+
+	task := tq.next()
+	if task == nil {
+		t.Fatal("flushCoalesced() did not enqueue a summary")
+	}
+	message := task.Message.(map[string]any)
+	if message["text"] != "Notifications suppressed: 3 messages to this chat were not delivered because its queue was full." {
+		t.Fatalf("summary = %#v", message)
+	}
+	if tq.coalesced != 0 {
+		t.Fatalf("coalesced = %d, want 0", tq.coalesced)
+	}
+}
+
+func TestDrainQueueFlushesCoalescedSummary(t *testing.T) {
+	m := newManager(config.QueueConfig{MaxAttempts: 1, CoalesceTitle: "部分消息未送达", CoalesceMessage: "{count} 条"}, nopStore{}, newMemStore())
+	svc := &fakeService{}
+	tq := newTestQueue(m, svc, 1)
+	tq.coalesced = 2
+
+	m.drainQueue(tq)
+	if len(svc.sent) != 1 || svc.sent[0].(map[string]any)["text"] != "部分消息未送达: 2 条" {
+		t.Fatalf("sent = %#v, want the summary", svc.sent)
+	}
+}
+
+func TestNextPrefersHigherPriorityWithoutStarvingLower(t *testing.T) {
+	m := newManager(config.QueueConfig{}, nopStore{}, newMemStore())
+	svc := &fakeService{}
+	tq := newTestQueue(m, svc, 100)
+
+	dispatch := func(id string, priority Priority) {
+		task := &Task{ID: id, Channel: service.ChannelTelegram, Target: "-100", Priority: priority, CreatedAt: time.Now()}
+		if err := m.dispatch(svc, task, 100); err != nil {
+			t.Fatal(err)
+		}
+	}
+	dispatch("low", PriorityLow)
+	dispatch("normal", PriorityNormal)
+	for i := range starvationLimit + 5 {
+		dispatch(fmt.Sprintf("high_%d", i), PriorityHigh)
+	}
+
+	var order []string
+	for task := tq.next(); task != nil; task = tq.next() {
+		order = append(order, task.ID)
+	}
+
+	if order[0] != "high_0" {
+		t.Fatalf("first = %s, want high_0", order[0])
+	}
+	lowAt := slices.Index(order, "low")
+	normalAt := slices.Index(order, "normal")
+	if normalAt != starvationLimit || lowAt != starvationLimit+1 {
+		t.Fatalf("order = %v", order)
+	}
+}
+
+func TestParsePriority(t *testing.T) {
+	if p, err := ParsePriority(""); err != nil || p != PriorityNormal {
+		t.Fatalf("ParsePriority(\"\") = %q, %v", p, err)
+	}
+	if _, err := ParsePriority("urgent"); err == nil {
+		t.Fatal("ParsePriority(\"urgent\") error = nil")
+	}
+}
+
+func TestProcessTaskRetriesByErrorKind(t *testing.T) {
+	cfg := config.QueueConfig{MaxAttempts: 5, RetryDelay: time.Hour}
+	m := newManager(cfg, nopStore{}, newMemStore())
+
+	// Rate-limited tasks go back to the scheduler without using an attempt
+	// and wait as long as the platform asks.
+	svc := &fakeService{errs: []error{service.RateLimitedError(100*time.Hour, "telegram error: 429 - Too Many Requests")}}
+	tq := newTestQueue(m, svc, 1)
+	task := &Task{ID: "task_1", Channel: service.ChannelTelegram, Target: "-100"}
+	start := time.Now()
+	m.processTask(m.ctx, tq, task)
+	if len(svc.sent) != 0 || task.Attempts != 0 || task.RateLimited != 1 {
+		t.Fatalf("sent = %d, attempts = %d, rateLimited = %d", len(svc.sent), task.Attempts, task.RateLimited)
+	}
+	scheduled := m.Scheduled()
+	if len(scheduled) != 1 {
+		t.Fatalf("Scheduled() = %#v", scheduled)
+	}
+	if due := scheduled[0].SendAt.Sub(start); due < 100*time.Hour || due > 100*time.Hour+time.Minute {
+		t.Fatalf("task_1 due in %s, want 100h", due)
+	}
+	if status, _ := m.TaskStatus("task_1"); status == nil || status.State != TaskRetrying {
+		t.Fatalf("TaskStatus() = %#v", status)
+	}
+
+	// Without a hint the backoff is capped by the attempt budget.
+	svc.errs = []error{service.RateLimitedError(0, "slack error: ratelimited")}
+	task = &Task{ID: "task_3", Channel: service.ChannelTelegram, Target: "-100", RateLimited: 20}
+	start = time.Now()
+	m.processTask(m.ctx, tq, task)
+	if due := task.SendAt.Sub(start); due > 32*time.Hour+time.Minute {
+		t.Fatalf("task_3 due in %s, want at most 32h", due)
+	}
+
+	svc.errs = []error{service.PermanentError("telegram error: 400 - Bad Request: chat not found")}
+	task = &Task{ID: "task_2", Channel: service.ChannelTelegram, Target: "-100"}
+	m.processTask(m.ctx, tq, task)
+	if task.Attempts != 1 {
+		t.Fatalf("attempts = %d, want 1 for permanent error", task.Attempts)
+	}
+	if status, _ := m.TaskStatus("task_2"); status == nil || status.State != TaskFailed {
+		t.Fatalf("TaskStatus() = %#v", status)
+	}
+}
+
+func TestRateLimitedTaskIsDeadLetteredAfterLimit(t *testing.T) {
+	m := newManager(config.QueueConfig{MaxAttempts: 3, RetryDelay: time.Second, MaxRateLimited: 2}, nopStore{}, newMemStore())
+	svc := &fakeService{errs: []error{service.RateLimitedError(time.Minute, "dingtalk error: 130101 - send too fast")}}
+	tq := newTestQueue(m, svc, 1)
+	task := &Task{ID: "task_1", Channel: service.ChannelTelegram, Target: "-100", RateLimited: 2}
+
+	m.processTask(m.ctx, tq, task)
+	if len(m.Scheduled()) != 0 {
+		t.Fatalf("Scheduled() = %#v, want none", m.Scheduled())
+	}
+	letters, _ := m.DeadLetters()
+	if len(letters) != 1 || letters[0].LastError != "dingtalk error: 130101 - send too fast" {
+		t.Fatalf("DeadLetters() = %#v", letters)
+	}
+}
+
+func TestDrainLeavesRateLimitedTaskForRestart(t *testing.T) {
+	store := newMemStore()
+	m := newManager(config.QueueConfig{MaxAttempts: 3, MaxRateLimited: 10}, store, newMemStore())
+	svc := &fakeService{errs: []error{service.RateLimitedError(time.Minute, "telegram error: 429 - Too Many Requests")}}
+	tq := newTestQueue(m, svc, 1)
+	task := &Task{ID: "task_1", Channel: service.ChannelTelegram, Target: "-100"}
+	if err := m.dispatch(svc, task, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	m.drainQueue(tq)
+	pending, _ := store.Pending()
+	if len(pending) != 1 || pending[0].Attempts != 0 || pending[0].RateLimited != 1 {
+		t.Fatalf("pending = %#v, want the task kept for restart", pending)
+	}
+	if letters, _ := m.DeadLetters(); len(letters) != 0 {
+		t.Fatalf("DeadLetters() = %#v, want none", letters)
+	}
+}
+
+func TestBackoffDoesNotOverflow(t *testing.T) {
+	m := newManager(config.QueueConfig{MaxAttempts: 100, RetryDelay: time.Second}, nopStore{}, newMemStore())
+	if d := m.maxRetryDelay(); d < time.Hour {
+		t.Fatalf("maxRetryDelay() = %s", d)
+	}
+	if d := m.backoff(100); d < time.Hour {
+		t.Fatalf("backoff(100) = %s", d)
+	}
+}
+
+// txnService records the transaction ID of each attempt.
+type txnService struct {
+	fakeService
+	txnIDs []string
+}
+
+func (s *txnService) SendRawMessageTxn(target string, message any, txnID string) (*service.SendResult, error) {
+	s.txnIDs = append(s.txnIDs, txnID)
+	return s.SendRawMessage(target, message)
+}
+
+func TestProcessTaskReusesTaskIDAsTransactionID(t *testing.T) {
+	m := newManager(config.QueueConfig{MaxAttempts: 3}, nopStore{}, newMemStore())
+	svc := &txnService{fakeService: fakeService{errs: []error{service.TransientError("timeout")}}}
+	tq := newTestQueue(m, svc, 1)
+
+	task := &Task{ID: "task_1", Channel: service.ChannelTelegram, Target: "-100"}
+	m.processTask(m.ctx, tq, task)
+	if !slices.Equal(svc.txnIDs, []string{"task_1", "task_1"}) {
+		t.Fatalf("txnIDs = %v", svc.txnIDs)
+	}
+}
+
+func TestBackoffIsJitteredAndExponential(t *testing.T) {
+	m := newManager(config.QueueConfig{RetryDelay: time.Second}, nopStore{}, newMemStore())
+	for attempts := 1; attempts <= 3; attempts++ {
+		full := time.Second << (attempts - 1)
+		for range 20 {
+			if delay := m.backoff(attempts); delay < full/2 || delay >= full {
+				t.Fatalf("backoff(%d) = %v, want [%v, %v)", attempts, delay, full/2, full)
+			}
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package queue
+
+import (
+	"container/heap"
+	"errors"
+	"log/slog"
+	"sort"
+	"time"
+
+	"notify/internal/service"
+)
+
+// taskHeap orders scheduled tasks by due time.
+type taskHeap []*Task
+
+func (h taskHeap) Len() int { return len(h) }
+
+func (h taskHeap) Less(i, j int) bool {
+	if h[i].SendAt.Equal(h[j].SendAt) {
+		return h[i].ID < h[j].ID
+	}
+	return h[i].SendAt.Before(h[j].SendAt)
+}
+
+func (h taskHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+func (h *taskHeap) Push(x any) { *h = append(*h, x.(*Task)) }
+
+func (h *taskHeap) Pop() any {
+	old := *h
+	task := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return task
+}
+
+// schedule holds a task until its SendAt time.
+func (m *Manager) schedule(task *Task) {
+	m.track(task, TaskScheduled, "")
+	slog.Info("Task scheduled", "taskId", task.ID, "channel", task.Channel, "target", task.Target, "sendAt", task.SendAt)
+	m.pushScheduled(task)
+}
+
+// pushScheduled adds task to the schedule and wakes the scheduler.
+func (m *Manager) pushScheduled(task *Task) {
+	m.scheduleMu.Lock()
+	heap.Push(&m.scheduled, task)
+	m.scheduleMu.Unlock()
+
+	select {
+	case m.scheduleWake <- struct{}{}:
+	default:
+	}
+}
+
+// runScheduler hands scheduled tasks to their target queues when they are due.
+func (m *Manager) runScheduler() {
+	defer m.wg.Done()
+
+	timer := time.NewTimer(time.Hour)
+	defer timer.Stop()
+
+	for {
+		next := m.dispatchDue(time.Now())
+		if next.IsZero() {
+			timer.Reset(time.Hour)
+		} else {
+			timer.Reset(time.Until(next))
+		}
+
+		select {
+		case <-m.ctx.Done():
+			// Scheduled tasks stay in the store and are restored on the next start.
+			return
+		case <-m.scheduleWake:
+		case <-timer.C:
+		}
+	}
+}
+
+// dispatchDue dispatches every task due at now and returns the due time of the
+// next scheduled task, or the zero time if none are left.
+func (m *Manager) dispatchDue(now time.Time) time.Time {
+	for {
+		m.scheduleMu.Lock()
+		if len(m.scheduled) == 0 {
+			m.scheduleMu.Unlock()
+			return time.Time{}
+		}
+		if m.scheduled[0].SendAt.After(now) {
+			next := m.scheduled[0].SendAt
+			m.scheduleMu.Unlock()
+			return next
+		}
+		task := heap.Pop(&m.scheduled).(*Task)
+		m.scheduleMu.Unlock()
+
+		svc, err := service.GetService(task.Channel)
+		if err != nil {
+			task.LastError = err.Error()
+			m.deadLetter(task)
+			continue
+		}
+
+		err = m.dispatch(svc, task, m.cfg.BufferSize)
+		var fullErr *QueueFullError
+		if errors.As(err, &fullErr) {
+			// The task was accepted when it was scheduled; try again later instead of dropping it.
+			task.SendAt = now.Add(fullErr.RetryAfter)
+			m.scheduleMu.Lock()
+			heap.Push(&m.scheduled, task)
+			m.scheduleMu.Unlock()
+			m.track(task, TaskScheduled, "")
+		} else if err != nil {
+			slog.Error("Failed to dispatch scheduled task", "taskId", task.ID, "error", err)
+		}
+	}
+}
+
+// Scheduled returns the tasks waiting for their send time, earliest first.
+func (m *Manager) Scheduled() []*Task {
+	m.scheduleMu.Lock()
+	defer m.scheduleMu.Unlock()
+
+	tasks := make([]*Task, len(m.scheduled))
+	for i, task := range m.scheduled {
+		snapshot := *task
+		tasks[i] = &snapshot
+	}
+	sort.Sort(taskHeap(tasks))
+	return tasks
+}
+
+// CancelScheduled removes a task that has not been handed to its target queue yet.
+func (m *Manager) CancelScheduled(id string) error {
+	m.scheduleMu.Lock()
+	var task *Task
+	for i, scheduled := range m.scheduled {
+		if scheduled.ID == id {
+			task = heap.Remove(&m.scheduled, i).(*Task)
+			break
+		}
+	}
+	m.scheduleMu.Unlock()
+
+	if task == nil {
+		return ErrTaskNotFound
+	}
+
+	slog.Info("Scheduled task cancelled", "taskId", id, "channel", task.Channel, "target", task.Target)
+	m.track(task, TaskCancelled, "")
+	m.releaseDedupKey(task)
+	m.finish(task)
+	return nil
+}
@@ -0,0 +1,52 @@
+package queue
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"notify/internal/config"
+	"notify/internal/service"
+)
+
+func TestDispatchDueHandsOverTasksInOrder(t *testing.T) {
+	if err := service.Init(&config.Config{Telegram: config.TelegramConfig{BotToken: "test"}}); err != nil {
+		t.Fatal(err)
+	}
+	m := newManager(config.QueueConfig{BufferSize: 10}, nopStore{}, newMemStore())
+	tq := newTestQueue(m, &fakeService{}, 10)
+
+	now := time.Now()
+	for _, task := range []*Task{
+		{ID: "task_late", SendAt: now.Add(time.Hour)},
+		{ID: "task_second", SendAt: now.Add(-time.Second)},
+		{ID: "task_first", SendAt: now.Add(-time.Minute)},
+	} {
+		task.Channel = service.ChannelTelegram
+		task.Target = "-100"
+		m.schedule(task)
+	}
+
+	if scheduled := m.Scheduled(); len(scheduled) != 3 || scheduled[0].ID != "task_first" {
+		t.Fatalf("Scheduled() = %#v", scheduled)
+	}
+
+	next := m.dispatchDue(now)
+	if !next.Equal(now.Add(time.Hour)) {
+		t.Fatalf("next = %v, want %v", next, now.Add(time.Hour))
+	}
+	if first, second := tq.next(), tq.next(); first.ID != "task_first" || second.ID != "task_second" {
+		t.Fatalf("dispatched %s, %s", first.ID, second.ID)
+	}
+
+	if err := m.CancelScheduled("task_late"); err != nil {
+		t.Fatalf("CancelScheduled() error = %v", err)
+	}
+	if err := m.CancelScheduled("task_late"); !errors.Is(err, ErrTaskNotFound) {
+		t.Fatalf("CancelScheduled() error = %v, want ErrTaskNotFound", err)
+	}
+	status, _ := m.TaskStatus("task_late")
+	if status == nil || status.State != TaskCancelled {
+		t.Fatalf("TaskStatus() = %#v", status)
+	}
+}
@@ -0,0 +1,181 @@
+package queue
+
+import (
+	"context"
+	"time"
+)
+
+type TaskState string
+
+const (
+	TaskScheduled TaskState = "scheduled"
+	TaskQueued    TaskState = "queued"
+	TaskSending   TaskState = "sending"
+	TaskRetrying  TaskState = "retrying"
+	TaskParked    TaskState = "parked"
+	TaskSent      TaskState = "sent"
+	TaskFailed    TaskState = "failed"
+	TaskDropped   TaskState = "dropped"
+	TaskCoalesced TaskState = "coalesced"
+	TaskCancelled TaskState = "cancelled"
+)
+
+type TaskStatus struct {
+	ID        string    `json:"id"`
+	Channel   string    `json:"channel"`
+	Target    string    `json:"target"`
+	State     TaskState `json:"state"`
+	Attempts  int       `json:"attempts"`
+	LastError string    `json:"lastError,omitempty"`
+	MessageID string    `json:"messageId,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+func (s TaskState) finished() bool {
+	switch s {
+	case TaskSent, TaskFailed, TaskDropped, TaskCoalesced, TaskCancelled:
+		return true
+	default:
+		return false
+	}
+}
+
+// track records the current state of a task. It must be called from the
+// goroutine that owns the task.
+func (m *Manager) track(task *Task, state TaskState, messageID string) {
+	m.statusesMu.Lock()
+	defer m.statusesMu.Unlock()
+
+	status := &TaskStatus{
+		ID:        task.ID,
+		Channel:   string(task.Channel),
+		Target:    task.Target,
+		State:     state,
+		Attempts:  task.Attempts,
+		LastError: task.LastError,
+		MessageID: messageID,
+		CreatedAt: task.CreatedAt,
+		UpdatedAt: time.Now(),
+	}
+	m.statuses[task.ID] = status
+	observe(task, state)
+
+	if w, ok := m.waiters[task.ID]; ok && state.finished() {
+		close(w.done)
+		delete(m.waiters, task.ID)
+	}
+}
+
+// waiter is closed when its task finishes; count is the number of Wait calls
+// blocked on it.
+type waiter struct {
+	done  chan struct{}
+	count int
+}
+
+// Wait blocks until the task reaches a final state or ctx is done, and returns
+// the latest status either way. A Wait that times out unregisters itself, so
+// tasks that never finish do not leave waiters behind.
+func (m *Manager) Wait(ctx context.Context, id string) (*TaskStatus, error) {
+	m.statusesMu.Lock()
+	status, ok := m.statuses[id]
+	if !ok {
+		m.statusesMu.Unlock()
+		return m.TaskStatus(id)
+	}
+	if status.State.finished() {
+		snapshot := *status
+		m.statusesMu.Unlock()
+		return &snapshot, nil
+	}
+	w, ok := m.waiters[id]
+	if !ok {
+		w = &waiter{done: make(chan struct{})}
+		m.waiters[id] = w
+	}
+	w.count++
+	m.statusesMu.Unlock()
+
+	select {
+	case <-w.done:
+	case <-ctx.Done():
+		m.statusesMu.Lock()
+		if w.count--; w.count == 0 && m.waiters[id] == w {
+			delete(m.waiters, id)
+		}
+		m.statusesMu.Unlock()
+	}
+	return m.TaskStatus(id)
+}
+
+// TaskStatus returns the latest known status of a task. Dead letters are
+// reported as failed even after their status has been pruned.
+func (m *Manager) TaskStatus(id string) (*TaskStatus, error) {
+	m.statusesMu.RLock()
+	status, ok := m.statuses[id]
+	m.statusesMu.RUnlock()
+	if ok {
+		snapshot := *status
+		return &snapshot, nil
+	}
+
+	task, err := m.findDeadLetter(id)
+	if err != nil {
+		return nil, err
+	}
+	return &TaskStatus{
+		ID:        task.ID,
+		Channel:   string(task.Channel),
+		Target:    task.Target,
+		State:     TaskFailed,
+		Attempts:  task.Attempts,
+		LastError: task.LastError,
+		CreatedAt: task.CreatedAt,
+		UpdatedAt: task.FailedAt,
+	}, nil
+}
+
+func (m *Manager) pruneStatuses(now time.Time) {
+	m.statusesMu.Lock()
+	defer m.statusesMu.Unlock()
+
+	for id, status := range m.statuses {
+		if status.State.finished() && now.Sub(status.UpdatedAt) > m.cfg.StatusRetention {
+			delete(m.statuses, id)
+		}
+	}
+}
+
+// QueueStats summarizes the buffered work across target queues. It is served
+// without authentication, so it counts saturated queues rather than naming
+// their targets.
+type QueueStats struct {
+	Queues    int `json:"queues"`
+	Depth     int `json:"depth"`
+	Capacity  int `json:"capacity"`
+	Saturated int `json:"saturated"`
+}
+
+// Stats reports the total depth and capacity of the target queues and how
+// many of them are full.
+func (m *Manager) Stats() QueueStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stats := QueueStats{Queues: len(m.queues)}
+	for _, tq := range m.queues {
+		depth := tq.len()
+		stats.Depth += depth
+		stats.Capacity += tq.capacity
+		if depth >= tq.capacity {
+			stats.Saturated++
+		}
+	}
+	return stats
+}
+
+// Draining reports whether Shutdown has started.
+func (m *Manager) Draining() bool {
+	return m.ctx.Err() != nil
+}
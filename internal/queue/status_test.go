@@ -0,0 +1,49 @@
+package queue
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"notify/internal/config"
+)
+
+func TestWaitReturnsFinalStatus(t *testing.T) {
+	m := newManager(config.QueueConfig{}, nopStore{}, newMemStore())
+	task := &Task{ID: "task_1", CreatedAt: time.Now()}
+	m.track(task, TaskQueued, "")
+
+	go func() {
+		task.Attempts = 1
+		m.track(task, TaskSending, "")
+		m.track(task, TaskSent, "om_123")
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	status, err := m.Wait(ctx, "task_1")
+	if err != nil {
+		t.Fatalf("Wait() error = %v", err)
+	}
+	if status.State != TaskSent || status.MessageID != "om_123" || status.Attempts != 1 {
+		t.Fatalf("Wait() = %#v", status)
+	}
+}
+
+func TestWaitTimesOutWithCurrentStatus(t *testing.T) {
+	m := newManager(config.QueueConfig{}, nopStore{}, newMemStore())
+	m.track(&Task{ID: "task_1", CreatedAt: time.Now()}, TaskRetrying, "")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	status, err := m.Wait(ctx, "task_1")
+	if err != nil {
+		t.Fatalf("Wait() error = %v", err)
+	}
+	if status.State != TaskRetrying {
+		t.Fatalf("Wait() state = %q, want retrying", status.State)
+	}
+	if len(m.waiters) != 0 {
+		t.Fatalf("waiters = %v, want none after timeout", m.waiters)
+	}
+}
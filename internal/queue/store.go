@@ -0,0 +1,280 @@
+package queue
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+const (
+	taskLogName       = "tasks.log"
+	deadLetterLogName = "deadletters.log"
+	// compactThreshold is the number of obsolete records tolerated in the
+	// log before it is rewritten with only the pending tasks.
+	compactThreshold = 1000
+)
+
+// taskStore keeps a set of tasks keyed by ID. The queue uses one for accepted
+// tasks that are not finished yet and one for dead letters.
+type taskStore interface {
+	Put(task *Task) error
+	Done(id string) error
+	Pending() ([]*Task, error)
+	Close() error
+}
+
+// nopStore keeps nothing; used when no data directory is configured.
+type nopStore struct{}
+
+func (nopStore) Put(*Task) error           { return nil }
+func (nopStore) Done(string) error         { return nil }
+func (nopStore) Pending() ([]*Task, error) { return nil, nil }
+func (nopStore) Close() error              { return nil }
+
+// memStore keeps tasks in memory only.
+type memStore struct {
+	tasks map[string]*Task
+	mu    sync.Mutex
+}
+
+func newMemStore() *memStore {
+	return &memStore{tasks: make(map[string]*Task)}
+}
+
+func (s *memStore) Put(task *Task) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snapshot := *task
+	s.tasks[task.ID] = &snapshot
+	return nil
+}
+
+func (s *memStore) Done(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.tasks, id)
+	return nil
+}
+
+func (s *memStore) Pending() ([]*Task, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return snapshotTasks(s.tasks), nil
+}
+
+func (s *memStore) Close() error { return nil }
+
+type storeRecord struct {
+	Op   string `json:"op"`
+	Task *Task  `json:"task,omitempty"`
+	ID   string `json:"id,omitempty"`
+}
+
+const (
+	storeOpPut  = "put"
+	storeOpDone = "done"
+)
+
+// fileStore is an append-only JSON lines log. Puts are synced to disk before
+// returning; done markers are not, so a crash may cause a finished task to be
+// delivered again but never lose an accepted one.
+type fileStore struct {
+	path    string
+	file    *os.File
+	pending map[string]*Task
+	garbage int
+	mu      sync.Mutex
+}
+
+func openFileStore(dir, name string) (*fileStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create data dir: %w", err)
+	}
+
+	s := &fileStore{
+		path:    filepath.Join(dir, name),
+		pending: make(map[string]*Task),
+	}
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	// Start every run from a compacted log.
+	if err := s.compact(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *fileStore) load() error {
+	f, err := os.Open(s.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("open task log: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var rec storeRecord
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			// A torn write at the tail of the log is expected after a crash.
+			continue
+		}
+		switch rec.Op {
+		case storeOpPut:
+			if rec.Task != nil {
+				s.pending[rec.Task.ID] = rec.Task
+			}
+		case storeOpDone:
+			delete(s.pending, rec.ID)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("read task log: %w", err)
+	}
+	return nil
+}
+
+func (s *fileStore) Put(task *Task) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.append(storeRecord{Op: storeOpPut, Task: task}); err != nil {
+		return err
+	}
+	if err := s.file.Sync(); err != nil {
+		return fmt.Errorf("sync task log: %w", err)
+	}
+	// Keep a snapshot; the worker mutates the live task while sending.
+	snapshot := *task
+	s.pending[task.ID] = &snapshot
+	return nil
+}
+
+func (s *fileStore) Done(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.pending[id]; !ok {
+		return nil
+	}
+	if err := s.append(storeRecord{Op: storeOpDone, ID: id}); err != nil {
+		return err
+	}
+	delete(s.pending, id)
+
+	s.garbage += 2
+	if s.garbage >= compactThreshold {
+		return s.compact()
+	}
+	return nil
+}
+
+func (s *fileStore) Pending() ([]*Task, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return snapshotTasks(s.pending), nil
+}
+
+func (s *fileStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Close()
+	s.file = nil
+	return err
+}
+
+func (s *fileStore) append(rec storeRecord) error {
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("marshal task record: %w", err)
+	}
+	if _, err := s.file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("write task log: %w", err)
+	}
+	return nil
+}
+
+// compact rewrites the log with only the pending tasks. Caller must hold s.mu
+// or be the only user of s.
+func (s *fileStore) compact() error {
+	tmpPath := s.path + ".tmp"
+	tmp, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("create task log: %w", err)
+	}
+
+	w := bufio.NewWriter(tmp)
+	for _, task := range sortTasks(s.pending) {
+		line, err := json.Marshal(storeRecord{Op: storeOpPut, Task: task})
+		if err != nil {
+			tmp.Close()
+			return fmt.Errorf("marshal task record: %w", err)
+		}
+		w.Write(line)
+		w.WriteByte('\n')
+	}
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write task log: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("sync task log: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("close task log: %w", err)
+	}
+	if err := os.Rename(tmpPath, s.path); err != nil {
+		return fmt.Errorf("replace task log: %w", err)
+	}
+
+	if s.file != nil {
+		s.file.Close()
+	}
+	s.file, err = os.OpenFile(s.path, os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("open task log: %w", err)
+	}
+	s.garbage = 0
+	return nil
+}
+
+// snapshotTasks returns copies of tasks ordered by creation time.
+func snapshotTasks(tasks map[string]*Task) []*Task {
+	sorted := sortTasks(tasks)
+	for i, task := range sorted {
+		snapshot := *task
+		sorted[i] = &snapshot
+	}
+	return sorted
+}
+
+func sortTasks(tasks map[string]*Task) []*Task {
+	sorted := make([]*Task, 0, len(tasks))
+	for _, task := range tasks {
+		sorted = append(sorted, task)
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].CreatedAt.Equal(sorted[j].CreatedAt) {
+			return sorted[i].ID < sorted[j].ID
+		}
+		return sorted[i].CreatedAt.Before(sorted[j].CreatedAt)
+	})
+	return sorted
+}
@@ -0,0 +1,76 @@
+package queue
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"notify/internal/service"
+)
+
+func TestFileStoreReplaysPendingTasks(t *testing.T) {
+	dir := t.TempDir()
+
+	store, err := openFileStore(dir, taskLogName)
+	if err != nil {
+		t.Fatalf("openFileStore() error = %v", err)
+	}
+	now := time.Now()
+	for i, id := range []string{"task_1", "task_2", "task_3"} {
+		task := &Task{
+			ID:        id,
+			Channel:   service.ChannelTelegram,
+			Target:    "-100123",
+			Message:   map[string]any{"text": id},
+			CreatedAt: now.Add(time.Duration(i) * time.Second),
+		}
+		if err := store.Put(task); err != nil {
+			t.Fatalf("Put(%s) error = %v", id, err)
+		}
+	}
+	if err := store.Done("task_2"); err != nil {
+		t.Fatalf("Done() error = %v", err)
+	}
+	if err := store.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	reopened, err := openFileStore(dir, taskLogName)
+	if err != nil {
+		t.Fatalf("openFileStore() error = %v", err)
+	}
+	defer reopened.Close()
+
+	tasks, err := reopened.Pending()
+	if err != nil {
+		t.Fatalf("Pending() error = %v", err)
+	}
+	if len(tasks) != 2 || tasks[0].ID != "task_1" || tasks[1].ID != "task_3" {
+		t.Fatalf("Pending() = %#v", tasks)
+	}
+	message, ok := tasks[1].Message.(map[string]any)
+	if !ok || message["text"] != "task_3" {
+		t.Fatalf("Message = %#v", tasks[1].Message)
+	}
+}
+
+func TestFileStoreIgnoresTornTail(t *testing.T) {
+	dir := t.TempDir()
+	log := `{"op":"put","task":{"id":"task_1","channel":"feishu","target":"oc_1","message":{},"attempts":0,"createdAt":"2026-01-01T00:00:00Z"}}
+{"op":"put","task":{"id":"task_2","chan`
+	if err := os.WriteFile(filepath.Join(dir, taskLogName), []byte(log), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	store, err := openFileStore(dir, taskLogName)
+	if err != nil {
+		t.Fatalf("openFileStore() error = %v", err)
+	}
+	defer store.Close()
+
+	tasks, _ := store.Pending()
+	if len(tasks) != 1 || tasks[0].ID != "task_1" {
+		t.Fatalf("Pending() = %#v", tasks)
+	}
+}
@@ -0,0 +1,88 @@
+package service
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"notify/internal/config"
+)
+
+// barkLevels maps message colors to iOS interruption levels. critical plays
+// a sound even when the phone is muted.
+var barkLevels = map[Color]string{
+	ColorBlue:   "active",
+	ColorGreen:  "active",
+	ColorOrange: "timeSensitive",
+	ColorGrey:   "passive",
+	ColorRed:    "critical",
+	ColorPurple: "active",
+}
+
+type BarkService struct {
+	serverURL string
+	devices   map[string]string
+	client    *http.Client
+}
+
+func NewBarkService(cfg config.BarkConfig) *BarkService {
+	return &BarkService{
+		serverURL: strings.TrimSuffix(cfg.ServerURL, "/"),
+		devices:   cfg.Devices,
+		client:    &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+func (s *BarkService) Channel() Channel {
+	return ChannelBark
+}
+
+func (s *BarkService) BuildMessage(params MessageParams) any {
+	return s.buildMessage(params)
+}
+
+func (s *BarkService) SendMessage(target string, params MessageParams) (*SendResult, error) {
+	return s.SendRawMessage(target, s.buildMessage(params))
+}
+
+// SendRawMessage pushes to the device named by target, or to target as a
+// device key.
+func (s *BarkService) SendRawMessage(target string, message any) (*SendResult, error) {
+	// A raw device key is a credential, so only device names are logged.
+	key, ok := s.devices[target]
+	if ok {
+		slog.Info("Sending Bark message", "target", target)
+	} else {
+		key = target
+		slog.Info("Sending Bark message", "target", "device key")
+	}
+
+	// The server answers with its own code, which mirrors the HTTP status.
+	var result struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	}
+	if err := postPushJSON(s.client, "bark", s.serverURL+"/push", nil, withField(message, "device_key", key), key, &result); err != nil {
+		return nil, err
+	}
+	if result.Code != http.StatusOK {
+		return nil, &SendError{Kind: statusErrorKind(result.Code), Err: fmt.Errorf("bark error: %d - %s", result.Code, result.Message)}
+	}
+	return &SendResult{Success: true}, nil
+}
+
+func (s *BarkService) buildMessage(params MessageParams) map[string]any {
+	message := map[string]any{"body": pushText(params)}
+	if params.Title != "" {
+		message["title"] = params.Title
+	}
+	if params.URL != "" {
+		message["url"] = params.URL
+	}
+	if level, ok := barkLevels[params.Color]; ok {
+		message["level"] = level
+	}
+	return message
+}
@@ -0,0 +1,56 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"notify/internal/config"
+)
+
+func TestBarkSendMessage(t *testing.T) {
+	var gotBody map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotBody = nil
+		_ = json.NewDecoder(r.Body).Decode(&gotBody)
+		if gotBody["device_key"] == "unknown-key" {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"code":400,"message":"failed to get device token"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"code":200,"message":"success"}`))
+	}))
+	defer srv.Close()
+
+	svc := NewBarkService(config.BarkConfig{ServerURL: srv.URL, Devices: map[string]string{"alice": "alice-key"}})
+
+	if _, err := svc.SendMessage("alice", MessageParams{Title: "Disk full", Color: ColorRed, Content: "host-1"}); err != nil {
+		t.Fatalf("SendMessage() error = %v", err)
+	}
+	if gotBody["device_key"] != "alice-key" || gotBody["title"] != "Disk full" || gotBody["body"] != "host-1" ||
+		gotBody["level"] != "critical" {
+		t.Fatalf("body = %v", gotBody)
+	}
+
+	var logs bytes.Buffer
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(slog.New(slog.NewTextHandler(&logs, nil)))
+	if _, err := svc.SendMessage("raw-key", MessageParams{Content: "hi"}); err != nil {
+		t.Fatalf("SendMessage(raw key) error = %v", err)
+	}
+	if gotBody["device_key"] != "raw-key" {
+		t.Fatalf("body = %v", gotBody)
+	}
+	if strings.Contains(logs.String(), "raw-key") {
+		t.Fatalf("logs contain the device key: %s", logs.String())
+	}
+
+	_, err := svc.SendMessage("unknown-key", MessageParams{Content: "hi"})
+	if kind, _ := ClassifyError(err); kind != ErrorPermanent {
+		t.Fatalf("400 = %v; error = %v", kind, err)
+	}
+}
@@ -0,0 +1,203 @@
+package service
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"notify/internal/config"
+)
+
+// DingTalk robot error codes that need special handling.
+const (
+	dingTalkCodeRateLimited    = 130101 // more than 20 messages per minute
+	dingTalkCodeSecurityFailed = 310000 // bad signature, keyword or IP
+	dingTalkCodeInvalidToken   = 300001
+	dingTalkCodeTokenNotFound  = 400101
+	dingTalkCodeChatDisbanded  = 400013
+	dingTalkCodeRobotDisabled  = 410100
+)
+
+// dingTalkBlockDuration is how long DingTalk blocks a robot that exceeded its
+// rate limit.
+const dingTalkBlockDuration = 10 * time.Minute
+
+type DingTalkService struct {
+	robots map[string]config.DingTalkRobot
+	apiURL string
+	client *http.Client
+}
+
+func NewDingTalkService(cfg config.DingTalkConfig) *DingTalkService {
+	return &DingTalkService{
+		robots: cfg.Robots,
+		apiURL: strings.TrimSuffix(cfg.APIURL, "/"),
+		client: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+func (s *DingTalkService) Channel() Channel {
+	return ChannelDingTalk
+}
+
+func (s *DingTalkService) BuildMessage(params MessageParams) any {
+	return s.buildMessage(params)
+}
+
+func (s *DingTalkService) SendMessage(target string, params MessageParams) (*SendResult, error) {
+	return s.SendRawMessage(target, s.buildMessage(params))
+}
+
+// SendRawMessage posts to the robot named by target.
+func (s *DingTalkService) SendRawMessage(target string, message any) (*SendResult, error) {
+	slog.Info("Sending DingTalk message", "target", target)
+
+	robot, ok := s.robots[target]
+	if !ok {
+		return nil, PermanentError("dingtalk: unknown robot %q", target)
+	}
+
+	body, err := json.Marshal(message)
+	if err != nil {
+		return nil, fmt.Errorf("marshal message: %w", err)
+	}
+
+	resp, err := s.client.Post(s.robotURL(robot, time.Now()), "application/json", bytes.NewReader(body))
+	if err != nil {
+		return nil, TransientError("send message: %w", redactURLError(err, robot.AccessToken))
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		ErrCode int    `json:"errcode"`
+		ErrMsg  string `json:"errmsg"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		if resp.StatusCode != http.StatusOK {
+			return nil, sendStatusError(resp.StatusCode)
+		}
+		return nil, TransientError("decode response: %w", err)
+	}
+	if result.ErrCode != 0 {
+		return nil, dingTalkError(result.ErrCode, result.ErrMsg)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, sendStatusError(resp.StatusCode)
+	}
+
+	return &SendResult{Success: true}, nil
+}
+
+// robotURL builds the send URL, adding timestamp and sign for robots with a
+// secret: sign = base64(HMAC-SHA256(secret, timestamp + "\n" + secret)).
+func (s *DingTalkService) robotURL(robot config.DingTalkRobot, now time.Time) string {
+	query := url.Values{"access_token": {robot.AccessToken}}
+	if robot.Secret != "" {
+		timestamp := strconv.FormatInt(now.UnixMilli(), 10)
+		query.Set("timestamp", timestamp)
+		query.Set("sign", dingTalkSign(robot.Secret, timestamp))
+	}
+	return s.apiURL + "/robot/send?" + query.Encode()
+}
+
+func dingTalkSign(secret, timestamp string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "\n" + secret))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func dingTalkError(code int, msg string) error {
+	switch code {
+	case dingTalkCodeRateLimited:
+		return RateLimitedError(dingTalkBlockDuration, "dingtalk error: %d - %s", code, msg)
+	case dingTalkCodeSecurityFailed, dingTalkCodeInvalidToken, dingTalkCodeTokenNotFound,
+		dingTalkCodeChatDisbanded, dingTalkCodeRobotDisabled:
+		return PermanentError("dingtalk error: %d - %s", code, msg)
+	default:
+		return TransientError("dingtalk error: %d - %s", code, msg)
+	}
+}
+
+// buildMessage renders params as markdown, or as a single-button actionCard
+// when there is a URL. actionCard cannot @ anyone, so messages with mentions
+// stay markdown and show the URL as a link.
+func (s *DingTalkService) buildMessage(params MessageParams) map[string]any {
+	title := params.Title
+	if title == "" {
+		title = firstLine(params.Content)
+	}
+
+	var parts []string
+	if params.Title != "" {
+		heading := params.Title
+		if color := colorHex(params.Color); color != "" {
+			heading = fmt.Sprintf(`<font color="%s">%s</font>`, color, heading)
+		}
+		parts = append(parts, "### "+heading)
+	}
+	if params.Content != "" {
+		parts = append(parts, params.Content)
+	}
+	if params.Note != "" {
+		parts = append(parts, "> "+strings.ReplaceAll(params.Note, "\n", "\n> "))
+	}
+
+	if params.URL != "" && len(params.Mentions) == 0 {
+		return map[string]any{
+			"msgtype": "actionCard",
+			"actionCard": map[string]any{
+				"title":       title,
+				"text":        strings.Join(parts, "\n\n"),
+				"singleTitle": "View Details",
+				"singleURL":   params.URL,
+			},
+		}
+	}
+
+	if params.URL != "" {
+		parts = append(parts, fmt.Sprintf("[View Details](%s)", params.URL))
+	}
+
+	// Mobiles must also appear in the text for DingTalk to highlight them.
+	atAll := slices.Contains(params.Mentions, "all")
+	var mobiles []string
+	for _, mention := range params.Mentions {
+		if mention != "all" {
+			mobiles = append(mobiles, mention)
+		}
+	}
+	if len(params.Mentions) > 0 {
+		var ats []string
+		for _, mention := range params.Mentions {
+			ats = append(ats, "@"+mention)
+		}
+		parts = append(parts, strings.Join(ats, " "))
+	}
+
+	return map[string]any{
+		"msgtype": "markdown",
+		"markdown": map[string]any{
+			"title": title,
+			"text":  strings.Join(parts, "\n\n"),
+		},
+		"at": map[string]any{
+			"atMobiles": mobiles,
+			"isAtAll":   atAll,
+		},
+	}
+}
+
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(s, "\n")
+	return line
+}
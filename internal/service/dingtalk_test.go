@@ -0,0 +1,122 @@
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"notify/internal/config"
+)
+
+func TestDingTalkSendRawMessage(t *testing.T) {
+	var gotQuery map[string][]string
+	var gotBody map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotQuery = r.URL.Query()
+		_ = json.NewDecoder(r.Body).Decode(&gotBody)
+		switch r.URL.Query().Get("access_token") {
+		case "busy":
+			_, _ = w.Write([]byte(`{"errcode":130101,"errmsg":"send too fast, exceed 20 times per minute"}`))
+		case "bad":
+			_, _ = w.Write([]byte(`{"errcode":310000,"errmsg":"sign not match"}`))
+		default:
+			_, _ = w.Write([]byte(`{"errcode":0,"errmsg":"ok"}`))
+		}
+	}))
+	defer srv.Close()
+
+	svc := NewDingTalkService(config.DingTalkConfig{
+		Robots: map[string]config.DingTalkRobot{
+			"ops":   {AccessToken: "ops-token", Secret: "SECtest"},
+			"plain": {AccessToken: "plain-token"},
+			"busy":  {AccessToken: "busy"},
+			"bad":   {AccessToken: "bad"},
+		},
+		APIURL: srv.URL,
+	})
+
+	message := svc.BuildMessage(MessageParams{Content: "hi"})
+	if _, err := svc.SendRawMessage("ops", message); err != nil {
+		t.Fatalf("SendRawMessage() error = %v", err)
+	}
+	timestamp := gotQuery["timestamp"][0]
+	if _, err := strconv.ParseInt(timestamp, 10, 64); err != nil {
+		t.Fatalf("timestamp = %q", timestamp)
+	}
+	if gotQuery["access_token"][0] != "ops-token" || gotQuery["sign"][0] != dingTalkSign("SECtest", timestamp) {
+		t.Fatalf("query = %v", gotQuery)
+	}
+	if gotBody["msgtype"] != "markdown" {
+		t.Fatalf("body = %v", gotBody)
+	}
+
+	if _, err := svc.SendRawMessage("plain", message); err != nil {
+		t.Fatalf("SendRawMessage() error = %v", err)
+	}
+	if _, ok := gotQuery["sign"]; ok {
+		t.Fatalf("unsigned robot sent sign: %v", gotQuery)
+	}
+
+	_, err := svc.SendRawMessage("busy", message)
+	if kind, retryAfter := ClassifyError(err); kind != ErrorRateLimited || retryAfter != 10*time.Minute {
+		t.Fatalf("130101 = %v, %s; error = %v", kind, retryAfter, err)
+	}
+	_, err = svc.SendRawMessage("bad", message)
+	if kind, _ := ClassifyError(err); kind != ErrorPermanent {
+		t.Fatalf("310000 = %v; error = %v", kind, err)
+	}
+	_, err = svc.SendRawMessage("missing", message)
+	if kind, _ := ClassifyError(err); kind != ErrorPermanent {
+		t.Fatalf("unknown robot = %v; error = %v", kind, err)
+	}
+}
+
+func TestDingTalkSign(t *testing.T) {
+	// Computed with the Python sample from the DingTalk documentation.
+	if got, want := dingTalkSign("SECtest", "1700000000000"), "aZLLrriXgn05YbwaGR7knYsLeJADjr9NwLaNNKpxh4g="; got != want {
+		t.Fatalf("dingTalkSign() = %q, want %q", got, want)
+	}
+}
+
+func TestDingTalkBuildMessage(t *testing.T) {
+	svc := NewDingTalkService(config.DingTalkConfig{})
+
+	card := svc.buildMessage(MessageParams{Title: "Deploy", Color: ColorGreen, Content: "done", URL: "https://example.com"})
+	wantCard := map[string]any{
+		"msgtype": "actionCard",
+		"actionCard": map[string]any{
+			"title":       "Deploy",
+			"text":        "### <font color=\"#2EB67D\">Deploy</font>\n\ndone",
+			"singleTitle": "View Details",
+			"singleURL":   "https://example.com",
+		},
+	}
+	if !reflect.DeepEqual(card, wantCard) {
+		t.Fatalf("actionCard = %#v", card)
+	}
+
+	markdown := svc.buildMessage(MessageParams{
+		Content:  "disk full",
+		Note:     "host-1",
+		URL:      "https://example.com",
+		Mentions: []string{"13800000000", "all"},
+	})
+	wantMarkdown := map[string]any{
+		"msgtype": "markdown",
+		"markdown": map[string]any{
+			"title": "disk full",
+			"text":  "disk full\n\n> host-1\n\n[View Details](https://example.com)\n\n@13800000000 @all",
+		},
+		"at": map[string]any{
+			"atMobiles": []string{"13800000000"},
+			"isAtAll":   true,
+		},
+	}
+	if !reflect.DeepEqual(markdown, wantMarkdown) {
+		t.Fatalf("markdown = %#v", markdown)
+	}
+}
@@ -0,0 +1,197 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"notify/internal/config"
+)
+
+type DiscordService struct {
+	botToken string
+	webhooks map[string]string
+	apiURL   string
+	client   *http.Client
+}
+
+func NewDiscordService(cfg config.DiscordConfig) *DiscordService {
+	return &DiscordService{
+		botToken: cfg.BotToken,
+		webhooks: cfg.Webhooks,
+		apiURL:   strings.TrimSuffix(cfg.APIURL, "/"),
+		client:   &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+func (s *DiscordService) Channel() Channel {
+	return ChannelDiscord
+}
+
+func (s *DiscordService) BuildMessage(params MessageParams) any {
+	return s.buildEmbedMessage(params)
+}
+
+func (s *DiscordService) SendMessage(target string, params MessageParams) (*SendResult, error) {
+	return s.SendRawMessage(target, s.buildEmbedMessage(params))
+}
+
+// SendRawMessage sends through a webhook when target is a configured webhook
+// name, a webhook URL or "id/token", and otherwise to the channel ID target
+// with the bot token. A ":thread_id" suffix posts into a thread.
+func (s *DiscordService) SendRawMessage(target string, message any) (*SendResult, error) {
+	slog.Info("Sending Discord message", "target", target)
+
+	dest, threadID := target, ""
+	if idx := strings.LastIndex(target, ":"); idx != -1 {
+		if _, err := strconv.ParseUint(target[idx+1:], 10, 64); err == nil {
+			dest, threadID = target[:idx], target[idx+1:]
+		}
+	}
+
+	body, err := json.Marshal(message)
+	if err != nil {
+		return nil, fmt.Errorf("marshal message: %w", err)
+	}
+
+	if webhookURL, ok := s.webhookURL(dest); ok {
+		query := url.Values{"wait": {"true"}}
+		if threadID != "" {
+			query.Set("thread_id", threadID)
+		}
+		sep := "?"
+		if strings.Contains(webhookURL, "?") {
+			sep = "&"
+		}
+		return s.post(webhookURL+sep+query.Encode(), "", body, webhookURL)
+	}
+
+	if s.botToken == "" {
+		return nil, PermanentError("discord: %q is not a webhook and no bot token is configured", target)
+	}
+	// Threads are channels of their own for the bot API.
+	channelID := dest
+	if threadID != "" {
+		channelID = threadID
+	}
+	return s.post(s.apiURL+"/channels/"+url.PathEscape(channelID)+"/messages", "Bot "+s.botToken, body, s.botToken)
+}
+
+// webhookURL resolves a webhook name, URL or "id/token" pair. Webhook URLs in
+// targets are rebuilt on the API URL so callers cannot point requests at
+// arbitrary hosts.
+func (s *DiscordService) webhookURL(dest string) (string, bool) {
+	if webhookURL, ok := s.webhooks[dest]; ok {
+		return webhookURL, true
+	}
+	if _, pair, ok := strings.Cut(dest, "/webhooks/"); ok {
+		dest = pair
+	}
+	if id, token, ok := strings.Cut(dest, "/"); ok && id != "" && token != "" && !strings.Contains(token, "/") {
+		return s.apiURL + "/webhooks/" + url.PathEscape(id) + "/" + url.PathEscape(token), true
+	}
+	return "", false
+}
+
+// post sends a message and reads the created message ID. secret is removed
+// from transport errors.
+func (s *DiscordService) post(endpoint, authorization string, body []byte, secret string) (*SendResult, error) {
+	req, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", redactURLError(err, secret))
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, TransientError("send message: %w", redactURLError(err, secret))
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		ID         string  `json:"id"`
+		Code       int     `json:"code"`
+		Message    string  `json:"message"`
+		RetryAfter float64 `json:"retry_after"`
+	}
+	data, _ := io.ReadAll(resp.Body)
+	decodeErr := json.Unmarshal(data, &result)
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		// retry_after in the body is in seconds with millisecond precision.
+		retryAfter := time.Duration(result.RetryAfter * float64(time.Second))
+		if retryAfter <= 0 {
+			retryAfter = retryAfterHeader(resp.Header)
+		}
+		return nil, RateLimitedError(retryAfter, "discord error: 429 - %s", result.Message)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		if decodeErr != nil {
+			return nil, sendStatusError(resp.StatusCode)
+		}
+		return nil, statusError(resp, "discord error: %d - %d %s", resp.StatusCode, result.Code, result.Message)
+	}
+	if decodeErr != nil {
+		return nil, TransientError("decode response: %w", decodeErr)
+	}
+
+	return &SendResult{Success: true, MessageID: result.ID}, nil
+}
+
+// CheckHealth confirms the bot token. Webhooks cannot be checked up front.
+func (s *DiscordService) CheckHealth() error {
+	if s.botToken == "" {
+		return nil
+	}
+
+	req, err := http.NewRequest(http.MethodGet, s.apiURL+"/users/@me", nil)
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bot "+s.botToken)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("get current user: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("get current user: unexpected status: %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// buildEmbedMessage renders params as a single embed.
+func (s *DiscordService) buildEmbedMessage(params MessageParams) map[string]any {
+	embed := map[string]any{}
+	if params.Title != "" {
+		embed["title"] = params.Title
+	}
+	if params.Content != "" {
+		embed["description"] = params.Content
+	}
+	if color := colorHex(params.Color); color != "" {
+		// Embeds take the color as an integer.
+		value, _ := strconv.ParseInt(color[1:], 16, 32)
+		embed["color"] = value
+	}
+	if params.URL != "" {
+		embed["url"] = params.URL
+	}
+	if params.Note != "" {
+		embed["footer"] = map[string]any{"text": params.Note}
+	}
+
+	return map[string]any{"embeds": []any{embed}}
+}
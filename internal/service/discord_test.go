@@ -0,0 +1,85 @@
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"notify/internal/config"
+)
+
+func TestDiscordSendRawMessage(t *testing.T) {
+	var gotPath, gotQuery, gotAuth string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath, gotQuery, gotAuth = r.URL.Path, r.URL.RawQuery, r.Header.Get("Authorization")
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/channels/999/messages"):
+			w.WriteHeader(http.StatusTooManyRequests)
+			_, _ = w.Write([]byte(`{"message":"You are being rate limited.","retry_after":1.5,"global":false}`))
+		case strings.HasSuffix(r.URL.Path, "/channels/404/messages"):
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"message":"Unknown Channel","code":10003}`))
+		default:
+			_, _ = w.Write([]byte(`{"id":"1234567890"}`))
+		}
+	}))
+	defer srv.Close()
+
+	svc := NewDiscordService(config.DiscordConfig{
+		BotToken: "bot-token",
+		Webhooks: map[string]string{"alerts": srv.URL + "/api/webhooks/1/named-token"},
+		APIURL:   srv.URL + "/api",
+	})
+
+	tests := []struct {
+		target            string
+		path, query, auth string
+	}{
+		{target: "alerts", path: "/api/webhooks/1/named-token", query: "wait=true"},
+		{target: "2/pair-token:77", path: "/api/webhooks/2/pair-token", query: "thread_id=77&wait=true"},
+		{target: srv.URL + "/api/webhooks/3/url-token", path: "/api/webhooks/3/url-token", query: "wait=true"},
+		{target: "100", path: "/api/channels/100/messages", auth: "Bot bot-token"},
+		{target: "100:200", path: "/api/channels/200/messages", auth: "Bot bot-token"},
+	}
+	for _, tt := range tests {
+		result, err := svc.SendRawMessage(tt.target, map[string]any{"content": "hi"})
+		if err != nil {
+			t.Fatalf("SendRawMessage(%q) error = %v", tt.target, err)
+		}
+		if result.MessageID != "1234567890" || gotPath != tt.path || gotQuery != tt.query || gotAuth != tt.auth {
+			t.Fatalf("SendRawMessage(%q) sent to %s?%s auth %q, id %q", tt.target, gotPath, gotQuery, gotAuth, result.MessageID)
+		}
+	}
+
+	_, err := svc.SendRawMessage("999", map[string]any{"content": "hi"})
+	if kind, retryAfter := ClassifyError(err); kind != ErrorRateLimited || retryAfter != 1500*time.Millisecond {
+		t.Fatalf("429 = %v, %s; error = %v", kind, retryAfter, err)
+	}
+	_, err = svc.SendRawMessage("404", map[string]any{"content": "hi"})
+	if kind, _ := ClassifyError(err); kind != ErrorPermanent {
+		t.Fatalf("404 kind = %v; error = %v", kind, err)
+	}
+}
+
+func TestDiscordBuildMessage(t *testing.T) {
+	svc := NewDiscordService(config.DiscordConfig{})
+	got := svc.BuildMessage(MessageParams{Title: "Deploy", Content: "**done**", Color: ColorGreen, URL: "https://ci/1", Note: "prod"})
+
+	data, _ := json.Marshal(got)
+	var decoded map[string]any
+	_ = json.Unmarshal(data, &decoded)
+	want := map[string]any{"embeds": []any{map[string]any{
+		"title":       "Deploy",
+		"description": "**done**",
+		"color":       float64(0x2EB67D),
+		"url":         "https://ci/1",
+		"footer":      map[string]any{"text": "prod"},
+	}}}
+	if !reflect.DeepEqual(decoded, want) {
+		t.Fatalf("BuildMessage() = %v, want %v", decoded, want)
+	}
+}
@@ -0,0 +1,351 @@
+package service
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"log/slog"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+
+	"notify/internal/config"
+)
+
+const emailDialTimeout = 30 * time.Second
+
+// EmailMessage is the message format of the email channel. Raw messages use
+// the same fields.
+type EmailMessage struct {
+	Subject string `json:"subject"`
+	Text    string `json:"text,omitempty"`
+	HTML    string `json:"html,omitempty"`
+}
+
+type EmailService struct {
+	host     string
+	port     int
+	username string
+	password string
+	from     string
+	startTLS bool
+	allowed  []string
+}
+
+func NewEmailService(cfg config.EmailConfig) *EmailService {
+	return &EmailService{
+		host:     cfg.Host,
+		port:     cfg.Port,
+		username: cfg.Username,
+		password: cfg.Password,
+		from:     cfg.From,
+		startTLS: cfg.StartTLS,
+		allowed:  cfg.AllowedRecipients,
+	}
+}
+
+func (s *EmailService) Channel() Channel {
+	return ChannelEmail
+}
+
+func (s *EmailService) BuildMessage(params MessageParams) any {
+	return s.buildMessage(params)
+}
+
+func (s *EmailService) SendMessage(target string, params MessageParams) (*SendResult, error) {
+	return s.SendRawMessage(target, s.buildMessage(params))
+}
+
+// SendRawMessage mails message to target, an address or a comma-separated
+// list of addresses. Recipients the server rejects permanently are skipped
+// as long as one is accepted.
+func (s *EmailService) SendRawMessage(target string, message any) (*SendResult, error) {
+	slog.Info("Sending email", "target", target)
+
+	msg, err := toEmailMessage(message)
+	if err != nil {
+		return nil, PermanentError("email: %w", err)
+	}
+	recipients, err := mail.ParseAddressList(target)
+	if err != nil {
+		return nil, PermanentError("email: invalid recipients %q: %w", target, err)
+	}
+	for _, rcpt := range recipients {
+		if !s.allowsRecipient(rcpt.Address) {
+			return nil, PermanentError("email: recipient %s is not in APP_SMTP_ALLOWED_RECIPIENTS", rcpt.Address)
+		}
+	}
+	from, err := mail.ParseAddress(s.from)
+	if err != nil {
+		return nil, PermanentError("email: invalid sender %q: %w", s.from, err)
+	}
+
+	messageID := emailMessageID(from.Address)
+	data, err := composeEmail(from, recipients, messageID, msg)
+	if err != nil {
+		return nil, fmt.Errorf("compose email: %w", err)
+	}
+
+	client, err := s.dial()
+	if err != nil {
+		return nil, smtpError("connect", err)
+	}
+	defer client.Close()
+
+	if err := client.Mail(from.Address); err != nil {
+		return nil, smtpError("MAIL FROM", err)
+	}
+	accepted := 0
+	var rcptErr error
+	for _, rcpt := range recipients {
+		if err := client.Rcpt(rcpt.Address); err != nil {
+			var tpErr *textproto.Error
+			if !errors.As(err, &tpErr) || tpErr.Code < 500 {
+				return nil, smtpError("RCPT TO", err)
+			}
+			slog.Warn("Email recipient rejected", "recipient", rcpt.Address, "error", err)
+			rcptErr = err
+			continue
+		}
+		accepted++
+	}
+	if accepted == 0 {
+		return nil, smtpError("RCPT TO", rcptErr)
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return nil, smtpError("DATA", err)
+	}
+	if _, err := w.Write(data); err != nil {
+		return nil, smtpError("DATA", err)
+	}
+	if err := w.Close(); err != nil {
+		return nil, smtpError("DATA", err)
+	}
+	// The message is accepted once DATA completes; a failed QUIT does not
+	// undo that.
+	_ = client.Quit()
+
+	return &SendResult{Success: true, MessageID: messageID}, nil
+}
+
+// dial connects, upgrades to TLS when configured and authenticates.
+func (s *EmailService) dial() (*smtp.Client, error) {
+	addr := net.JoinHostPort(s.host, strconv.Itoa(s.port))
+	conn, err := net.DialTimeout("tcp", addr, emailDialTimeout)
+	if err != nil {
+		return nil, err
+	}
+	_ = conn.SetDeadline(time.Now().Add(2 * emailDialTimeout))
+
+	client, err := smtp.NewClient(conn, s.host)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	if s.startTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			client.Close()
+			return nil, PermanentError("server does not offer STARTTLS")
+		}
+		if err := client.StartTLS(&tls.Config{ServerName: s.host}); err != nil {
+			client.Close()
+			return nil, err
+		}
+	}
+
+	if s.username != "" {
+		if err := client.Auth(smtp.PlainAuth("", s.username, s.password, s.host)); err != nil {
+			client.Close()
+			return nil, err
+		}
+	}
+	return client, nil
+}
+
+// smtpError classifies SMTP failures: 5xx replies are permanent, 4xx replies
+// and network errors are transient.
+func smtpError(stage string, err error) error {
+	var sendErr *SendError
+	if errors.As(err, &sendErr) {
+		return fmt.Errorf("email: %s: %w", stage, err)
+	}
+	var tpErr *textproto.Error
+	if errors.As(err, &tpErr) && tpErr.Code >= 500 {
+		return PermanentError("email: %s: %w", stage, err)
+	}
+	return TransientError("email: %s: %w", stage, err)
+}
+
+// CheckHealth connects to the SMTP server and authenticates.
+func (s *EmailService) CheckHealth() error {
+	client, err := s.dial()
+	if err != nil {
+		return fmt.Errorf("connect: %w", err)
+	}
+	defer client.Close()
+	return client.Quit()
+}
+
+// toEmailMessage accepts an EmailMessage or its JSON form, as raw messages
+// and persisted tasks arrive.
+func toEmailMessage(message any) (EmailMessage, error) {
+	msg, ok := message.(EmailMessage)
+	if !ok {
+		data, err := json.Marshal(message)
+		if err != nil {
+			return msg, fmt.Errorf("marshal message: %w", err)
+		}
+		if err := json.Unmarshal(data, &msg); err != nil {
+			return msg, fmt.Errorf("message must be an object with subject, text and html: %w", err)
+		}
+	}
+	if msg.Text == "" && msg.HTML == "" {
+		return msg, fmt.Errorf("message needs text or html")
+	}
+	return msg, nil
+}
+
+// allowsRecipient matches address against the configured addresses and
+// "@domain" entries, ignoring case. An empty list allows any address.
+func (s *EmailService) allowsRecipient(address string) bool {
+	if len(s.allowed) == 0 {
+		return true
+	}
+	address = strings.ToLower(address)
+	_, domain, _ := strings.Cut(address, "@")
+	for _, allowed := range s.allowed {
+		allowed = strings.ToLower(allowed)
+		if allowed == address || allowed == "@"+domain {
+			return true
+		}
+	}
+	return false
+}
+
+func emailMessageID(fromAddress string) string {
+	buf := make([]byte, 16)
+	_, _ = rand.Read(buf)
+	domain := "localhost"
+	if _, d, ok := strings.Cut(fromAddress, "@"); ok {
+		domain = d
+	}
+	return "<" + hex.EncodeToString(buf) + "@" + domain + ">"
+}
+
+// emailHeaderReplacer keeps header values on one line.
+var emailHeaderReplacer = strings.NewReplacer("\r\n", " ", "\r", " ", "\n", " ")
+
+// composeEmail renders msg as an RFC 5322 message with text and HTML
+// alternatives.
+func composeEmail(from *mail.Address, to []*mail.Address, messageID string, msg EmailMessage) ([]byte, error) {
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+
+	recipients := make([]string, len(to))
+	for i, addr := range to {
+		recipients[i] = addr.String()
+	}
+
+	header := []string{
+		"From: " + from.String(),
+		"To: " + strings.Join(recipients, ", "),
+		"Subject: " + mime.QEncoding.Encode("utf-8", emailHeaderReplacer.Replace(msg.Subject)),
+		"Date: " + time.Now().Format(time.RFC1123Z),
+		"Message-ID: " + messageID,
+		"MIME-Version: 1.0",
+		`Content-Type: multipart/alternative; boundary="` + mw.Boundary() + `"`,
+	}
+	buf.WriteString(strings.Join(header, "\r\n") + "\r\n\r\n")
+
+	parts := []struct{ contentType, body string }{
+		{"text/plain; charset=utf-8", msg.Text},
+		{"text/html; charset=utf-8", msg.HTML},
+	}
+	for _, part := range parts {
+		if part.body == "" {
+			continue
+		}
+		w, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		qp := quotedprintable.NewWriter(w)
+		if _, err := qp.Write([]byte(part.body)); err != nil {
+			return nil, err
+		}
+		if err := qp.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// buildMessage renders params as a plain text part and an HTML part with a
+// colored header, the content rendered from Markdown, a link button and the
+// note in small print.
+func (s *EmailService) buildMessage(params MessageParams) EmailMessage {
+	subject := params.Title
+	if subject == "" {
+		subject = firstLine(params.Content)
+	}
+
+	var text []string
+	for _, part := range []string{params.Title, params.Content, params.URL, params.Note} {
+		if part != "" {
+			text = append(text, part)
+		}
+	}
+
+	color := colorHex(params.Color)
+	if color == "" {
+		color = colorHex(ColorGrey)
+	}
+
+	var b strings.Builder
+	b.WriteString(`<!DOCTYPE html><html><body style="margin:0;padding:16px;font-family:Arial,Helvetica,sans-serif;color:#1F2329">`)
+	b.WriteString(`<div style="max-width:640px;margin:0 auto;border:1px solid #E5E6EB;border-radius:6px;overflow:hidden">`)
+	if params.Title != "" {
+		fmt.Fprintf(&b, `<div style="background:%s;color:#FFFFFF;padding:12px 16px;font-size:18px;font-weight:bold">%s</div>`,
+			color, html.EscapeString(params.Title))
+	}
+	b.WriteString(`<div style="padding:16px;font-size:14px;line-height:1.5">`)
+	if params.Content != "" {
+		b.WriteString(markdownToHTML(params.Content))
+	}
+	if params.URL != "" && markdownSafeLinkURL.MatchString(params.URL) {
+		fmt.Fprintf(&b, `<p><a href="%s" style="display:inline-block;padding:8px 16px;background:%s;color:#FFFFFF;text-decoration:none;border-radius:4px">View Details</a></p>`,
+			html.EscapeString(params.URL), color)
+	}
+	if params.Note != "" {
+		fmt.Fprintf(&b, `<p style="color:#8F959E;font-size:12px">%s</p>`,
+			strings.ReplaceAll(html.EscapeString(params.Note), "\n", "<br>"))
+	}
+	b.WriteString(`</div></div></body></html>`)
+
+	return EmailMessage{
+		Subject: subject,
+		Text:    strings.Join(text, "\n\n"),
+		HTML:    b.String(),
+	}
+}
@@ -0,0 +1,239 @@
+package service
+
+import (
+	"bufio"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"notify/internal/config"
+)
+
+// smtpSink is a minimal SMTP server that records what it receives.
+type smtpSink struct {
+	listener net.Listener
+	reject   map[string]int // recipient -> reply code
+
+	mu    sync.Mutex
+	rcpts []string
+	data  string
+}
+
+func newSMTPSink(t *testing.T, reject map[string]int) *smtpSink {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sink := &smtpSink{listener: l, reject: reject}
+	go sink.serve()
+	t.Cleanup(func() { l.Close() })
+	return sink
+}
+
+func (s *smtpSink) config() config.EmailConfig {
+	host, port, _ := net.SplitHostPort(s.listener.Addr().String())
+	portNum, _ := strconv.Atoi(port)
+	return config.EmailConfig{Host: host, Port: portNum, From: "Notify <notify@example.com>"}
+}
+
+func (s *smtpSink) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *smtpSink) handle(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(line string) { _, _ = io.WriteString(conn, line+"\r\n") }
+
+	reply("220 sink ready")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		cmd := strings.TrimSpace(line)
+		switch upper := strings.ToUpper(cmd); {
+		case strings.HasPrefix(upper, "EHLO"), strings.HasPrefix(upper, "HELO"):
+			reply("250 sink")
+		case strings.HasPrefix(upper, "MAIL FROM:"):
+			reply("250 ok")
+		case strings.HasPrefix(upper, "RCPT TO:"):
+			rcpt := strings.Trim(cmd[len("RCPT TO:"):], "<> ")
+			if code, ok := s.reject[rcpt]; ok {
+				reply(strconv.Itoa(code) + " rejected")
+				continue
+			}
+			s.mu.Lock()
+			s.rcpts = append(s.rcpts, rcpt)
+			s.mu.Unlock()
+			reply("250 ok")
+		case upper == "DATA":
+			reply("354 go ahead")
+			var data strings.Builder
+			for {
+				line, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if line == ".\r\n" {
+					break
+				}
+				data.WriteString(strings.TrimPrefix(line, "."))
+			}
+			s.mu.Lock()
+			s.data = data.String()
+			s.mu.Unlock()
+			reply("250 queued")
+		case upper == "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("250 ok")
+		}
+	}
+}
+
+func TestEmailSendMessage(t *testing.T) {
+	sink := newSMTPSink(t, map[string]int{"gone@example.com": 550, "busy@example.com": 451})
+	svc := NewEmailService(sink.config())
+
+	result, err := svc.SendMessage("ops@example.com, Gone <gone@example.com>", MessageParams{
+		Title:   "Disk full",
+		Color:   ColorRed,
+		Content: "**host-1** at 95%",
+		URL:     "https://grafana.example.com",
+		Note:    "runbook",
+	})
+	if err != nil {
+		t.Fatalf("SendMessage() error = %v", err)
+	}
+	if !strings.HasSuffix(result.MessageID, "@example.com>") {
+		t.Fatalf("MessageID = %q", result.MessageID)
+	}
+
+	sink.mu.Lock()
+	rcpts, data := sink.rcpts, sink.data
+	sink.mu.Unlock()
+	if len(rcpts) != 1 || rcpts[0] != "ops@example.com" {
+		t.Fatalf("recipients = %v", rcpts)
+	}
+
+	msg, err := mail.ReadMessage(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("ReadMessage() error = %v", err)
+	}
+	if got := msg.Header.Get("Subject"); got != "Disk full" {
+		t.Fatalf("Subject = %q", got)
+	}
+	mediaType, mediaParams, _ := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if mediaType != "multipart/alternative" {
+		t.Fatalf("Content-Type = %q", mediaType)
+	}
+	mr := multipart.NewReader(msg.Body, mediaParams["boundary"])
+	var bodies []string
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("NextPart() error = %v", err)
+		}
+		body, _ := io.ReadAll(part)
+		bodies = append(bodies, string(body))
+	}
+	if len(bodies) != 2 || !strings.Contains(bodies[0], "https://grafana.example.com") ||
+		!strings.Contains(bodies[1], "<strong>host-1</strong> at 95%") || !strings.Contains(bodies[1], "#E01E5A") {
+		t.Fatalf("bodies = %q", bodies)
+	}
+
+	_, err = svc.SendMessage("gone@example.com", MessageParams{Content: "hi"})
+	if kind, _ := ClassifyError(err); kind != ErrorPermanent {
+		t.Fatalf("550 = %v; error = %v", kind, err)
+	}
+	_, err = svc.SendMessage("busy@example.com", MessageParams{Content: "hi"})
+	if kind, _ := ClassifyError(err); kind != ErrorTransient {
+		t.Fatalf("451 = %v; error = %v", kind, err)
+	}
+	_, err = svc.SendMessage("not an address", MessageParams{Content: "hi"})
+	if kind, _ := ClassifyError(err); kind != ErrorPermanent {
+		t.Fatalf("invalid address = %v; error = %v", kind, err)
+	}
+}
+
+func TestEmailRequiresStartTLS(t *testing.T) {
+	sink := newSMTPSink(t, nil)
+	cfg := sink.config()
+	cfg.StartTLS = true
+
+	_, err := NewEmailService(cfg).SendMessage("ops@example.com", MessageParams{Content: "hi"})
+	if kind, _ := ClassifyError(err); kind != ErrorPermanent {
+		t.Fatalf("no STARTTLS = %v; error = %v", kind, err)
+	}
+}
+
+func TestEmailAllowedRecipients(t *testing.T) {
+	sink := newSMTPSink(t, nil)
+	cfg := sink.config()
+	cfg.AllowedRecipients = []string{"oncall@corp.example", "@Example.com"}
+	svc := NewEmailService(cfg)
+
+	if _, err := svc.SendMessage("Ops@example.com, oncall@corp.example", MessageParams{Content: "hi"}); err != nil {
+		t.Fatalf("allowed recipients error = %v", err)
+	}
+	_, err := svc.SendMessage("ops@example.com, someone@elsewhere.example", MessageParams{Content: "hi"})
+	if kind, _ := ClassifyError(err); kind != ErrorPermanent {
+		t.Fatalf("disallowed recipient = %v; error = %v", kind, err)
+	}
+	sink.mu.Lock()
+	rcpts := sink.rcpts
+	sink.mu.Unlock()
+	if len(rcpts) != 2 {
+		t.Fatalf("recipients = %v", rcpts)
+	}
+}
+
+func TestEmailRawMessageNeedsBody(t *testing.T) {
+	svc := NewEmailService(config.EmailConfig{Host: "127.0.0.1", Port: 25, From: "notify@example.com"})
+	_, err := svc.SendRawMessage("ops@example.com", EmailMessage{Subject: "empty"})
+	if kind, _ := ClassifyError(err); kind != ErrorPermanent {
+		t.Fatalf("typed message without body = %v; error = %v", kind, err)
+	}
+}
+
+func TestMarkdownToHTML(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"# Title", "<h1>Title</h1>\n"},
+		{"line 1\nline 2\n\nnext", "<p>line 1<br>\nline 2</p>\n<p>next</p>\n"},
+		{"- a\n- *b*", "<ul>\n<li>a</li>\n<li><em>b</em></li>\n</ul>\n"},
+		{"1. a\n2. b", "<ol>\n<li>a</li>\n<li>b</li>\n</ol>\n"},
+		{"> quoted", "<blockquote>\n<p>quoted</p>\n</blockquote>\n"},
+		{"```\n<b>\n```", "<pre><code>&lt;b&gt;</code></pre>\n"},
+		{"`**x**` ~~y~~", "<p><code>**x**</code> <del>y</del></p>\n"},
+		{"[docs](https://example.com?a=1&b=2)", `<p><a href="https://example.com?a=1&amp;b=2">docs</a></p>` + "\n"},
+		{"*see* [**log**](https://x.example/a_b_c/__d__*e*) *now*",
+			`<p><em>see</em> <a href="https://x.example/a_b_c/__d__*e*"><strong>log</strong></a> <em>now</em></p>` + "\n"},
+		{"[x](javascript:alert(1))", "<p>[x](javascript:alert(1))</p>\n"},
+		{"<script>", "<p>&lt;script&gt;</p>\n"},
+	}
+	for _, tt := range tests {
+		if got := markdownToHTML(tt.in); got != tt.want {
+			t.Errorf("markdownToHTML(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrorKind tells the queue how to treat a failed send.
+type ErrorKind int
+
+const (
+	// ErrorTransient may succeed on retry, e.g. network errors and 5xx responses.
+	ErrorTransient ErrorKind = iota
+	// ErrorRateLimited should be retried after SendError.RetryAfter, or after
+	// the usual backoff when the platform gave no hint.
+	ErrorRateLimited
+	// ErrorPermanent will fail again, e.g. unknown chat, bot removed or invalid message.
+	ErrorPermanent
+)
+
+func (k ErrorKind) String() string {
+	switch k {
+	case ErrorRateLimited:
+		return "rate_limited"
+	case ErrorPermanent:
+		return "permanent"
+	default:
+		return "transient"
+	}
+}
+
+// SendError classifies an upstream failure.
+type SendError struct {
+	Kind       ErrorKind
+	RetryAfter time.Duration
+	Err        error
+}
+
+func (e *SendError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *SendError) Unwrap() error {
+	return e.Err
+}
+
+func RateLimitedError(retryAfter time.Duration, format string, args ...any) error {
+	return &SendError{Kind: ErrorRateLimited, RetryAfter: retryAfter, Err: fmt.Errorf(format, args...)}
+}
+
+func PermanentError(format string, args ...any) error {
+	return &SendError{Kind: ErrorPermanent, Err: fmt.Errorf(format, args...)}
+}
+
+func TransientError(format string, args ...any) error {
+	return &SendError{Kind: ErrorTransient, Err: fmt.Errorf(format, args...)}
+}
+
+// ClassifyError returns the kind of err and, for rate limits, how long the
+// platform asked to wait. Unclassified errors are transient.
+func ClassifyError(err error) (ErrorKind, time.Duration) {
+	var sendErr *SendError
+	if errors.As(err, &sendErr) {
+		return sendErr.Kind, sendErr.RetryAfter
+	}
+	return ErrorTransient, 0
+}
+
+// statusErrorKind classifies an HTTP status without a more specific platform
+// error code.
+func statusErrorKind(status int) ErrorKind {
+	switch {
+	case status == 429:
+		return ErrorRateLimited
+	case status >= 400 && status < 500 && status != 408:
+		return ErrorPermanent
+	default:
+		return ErrorTransient
+	}
+}
+
+// sendStatusError reports a non-200 response whose body could not be parsed.
+func sendStatusError(status int) error {
+	return &SendError{Kind: statusErrorKind(status), Err: fmt.Errorf("unexpected status: %d", status)}
+}
+
+// statusError classifies a failed HTTP response by status, taking the wait
+// for 429 from the Retry-After header.
+func statusError(resp *http.Response, format string, args ...any) error {
+	kind := statusErrorKind(resp.StatusCode)
+	var retryAfter time.Duration
+	if kind == ErrorRateLimited {
+		retryAfter = retryAfterHeader(resp.Header)
+	}
+	return &SendError{Kind: kind, RetryAfter: retryAfter, Err: fmt.Errorf(format, args...)}
+}
+
+// retryAfterHeader parses Retry-After given in seconds or as an HTTP date.
+func retryAfterHeader(header http.Header) time.Duration {
+	value := header.Get("Retry-After")
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return max(time.Until(at), 0)
+	}
+	return 0
+}
+
+// redactURLError replaces secret in the request URL that net/http includes in
+// transport errors, which end up in logs, task status and dead letters.
+func redactURLError(err error, secret string) error {
+	var urlErr *url.Error
+	if secret != "" && errors.As(err, &urlErr) {
+		urlErr.URL = strings.ReplaceAll(urlErr.URL, secret, "<redacted>")
+	}
+	return err
+}
@@ -0,0 +1,62 @@
+package service
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestTelegramErrorClassification(t *testing.T) {
+	tests := []struct {
+		status      int
+		description string
+		retryAfter  int
+		kind        ErrorKind
+		wait        time.Duration
+	}{
+		{http.StatusTooManyRequests, "Too Many Requests: retry after 17", 17, ErrorRateLimited, 17 * time.Second},
+		{http.StatusBadRequest, "Bad Request: chat not found", 0, ErrorPermanent, 0},
+		{http.StatusForbidden, "Forbidden: bot was kicked from the supergroup chat", 0, ErrorPermanent, 0},
+		{http.StatusBadGateway, "Bad Gateway", 0, ErrorTransient, 0},
+	}
+
+	for _, tt := range tests {
+		err := telegramError(tt.status, tt.status, tt.description, tt.retryAfter)
+		kind, wait := ClassifyError(err)
+		if kind != tt.kind || wait != tt.wait {
+			t.Errorf("telegramError(%d) = %v, %v; want %v, %v", tt.status, kind, wait, tt.kind, tt.wait)
+		}
+	}
+}
+
+func TestFeishuSendErrorClassification(t *testing.T) {
+	s := &FeishuService{token: "t-cached", tokenExp: time.Now().Add(time.Hour)}
+
+	header := http.Header{}
+	header.Set("x-ogw-ratelimit-reset", "3")
+	kind, wait := ClassifyError(s.sendError(&http.Response{StatusCode: http.StatusTooManyRequests, Header: header}, feishuCodeRateLimited, "request trigger frequency limit"))
+	if kind != ErrorRateLimited || wait != 3*time.Second {
+		t.Fatalf("rate limit = %v, %v", kind, wait)
+	}
+
+	kind, _ = ClassifyError(s.sendError(&http.Response{StatusCode: http.StatusBadRequest}, feishuCodeBotNotInChat, "Bot is not in the chat"))
+	if kind != ErrorPermanent {
+		t.Fatalf("bot not in chat = %v, want permanent", kind)
+	}
+
+	kind, _ = ClassifyError(s.sendError(&http.Response{StatusCode: http.StatusBadRequest}, feishuCodeInvalidToken, "Invalid access token"))
+	if kind != ErrorTransient || s.token != "" {
+		t.Fatalf("invalid token = %v, token = %q", kind, s.token)
+	}
+}
+
+func TestClassifyErrorDefaultsToTransient(t *testing.T) {
+	wrapped := fmt.Errorf("send: %w", PermanentError("bad card"))
+	if kind, _ := ClassifyError(wrapped); kind != ErrorPermanent {
+		t.Fatalf("wrapped kind = %v, want permanent", kind)
+	}
+	if kind, _ := ClassifyError(fmt.Errorf("boom")); kind != ErrorTransient {
+		t.Fatalf("plain kind = %v, want transient", kind)
+	}
+}
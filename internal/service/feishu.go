@@ -6,10 +6,12 @@ import (
 	"fmt"
 	"log/slog"
 	"net/http"
+	"strconv"
 	"sync"
 	"time"
 
 	"notify/internal/config"
+	"notify/internal/metrics"
 )
 
 const feishuBaseURL = "https://open.feishu.cn"
@@ -77,27 +79,30 @@ func (s *FeishuService) SendRawMessage(target string, message any) (*SendResult,
 
 	resp, err := s.client.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("send message: %w", err)
+		return nil, TransientError("send message: %w", err)
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected status: %d", resp.StatusCode)
-	}
-
+	// Feishu returns the error code in the body for 4xx responses as well.
 	var result struct {
 		Code int    `json:"code"`
 		Msg  string `json:"msg"`
+		Data struct {
+			MessageID string `json:"message_id"`
+		} `json:"data"`
 	}
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("decode response: %w", err)
+		if resp.StatusCode != http.StatusOK {
+			return nil, sendStatusError(resp.StatusCode)
+		}
+		return nil, TransientError("decode response: %w", err)
 	}
 
-	if result.Code != 0 {
-		return nil, fmt.Errorf("feishu error: %d - %s", result.Code, result.Msg)
+	if result.Code != 0 || resp.StatusCode != http.StatusOK {
+		return nil, s.sendError(resp, result.Code, result.Msg)
 	}
 
-	return &SendResult{Success: true}, nil
+	return &SendResult{Success: true, MessageID: result.Data.MessageID}, nil
 }
 
 func (s *FeishuService) ListChats() ([]ChatItem, error) {
@@ -152,6 +157,19 @@ func (s *FeishuService) ListChats() ([]ChatItem, error) {
 	return chats, nil
 }
 
+// CheckHealth confirms the app credentials can obtain a tenant access token.
+func (s *FeishuService) CheckHealth() error {
+	_, err := s.getTenantAccessToken()
+	return err
+}
+
+var (
+	feishuTokenRefreshes = metrics.NewCounterVec("notify_feishu_token_refreshes_total",
+		"Feishu tenant access token refresh requests.")
+	feishuTokenRefreshFailures = metrics.NewCounterVec("notify_feishu_token_refresh_failures_total",
+		"Feishu tenant access token refresh requests that failed.")
+)
+
 func (s *FeishuService) getTenantAccessToken() (string, error) {
 	s.tokenMu.RLock()
 	if s.token != "" && time.Now().Before(s.tokenExp) {
@@ -169,6 +187,17 @@ func (s *FeishuService) getTenantAccessToken() (string, error) {
 		return s.token, nil
 	}
 
+	token, err := s.refreshTenantAccessToken()
+	feishuTokenRefreshes.Inc()
+	if err != nil {
+		feishuTokenRefreshFailures.Inc()
+		return "", err
+	}
+	return token, nil
+}
+
+// refreshTenantAccessToken fetches a new token. Caller must hold s.tokenMu.
+func (s *FeishuService) refreshTenantAccessToken() (string, error) {
 	reqBody := map[string]string{
 		"app_id":     s.appID,
 		"app_secret": s.appSecret,
@@ -213,6 +242,46 @@ func (s *FeishuService) getTenantAccessToken() (string, error) {
 	return result.TenantAccessToken, nil
 }
 
+// Feishu error codes that need special handling when sending messages.
+const (
+	feishuCodeRateLimited        = 99991400
+	feishuCodeInvalidToken       = 99991663
+	feishuCodeTokenExpired       = 99991677
+	feishuCodeInvalidParam       = 230001
+	feishuCodeBotNotInChat       = 230002
+	feishuCodeBotDisabled        = 230006
+	feishuCodeNoAvailability     = 230013
+	feishuCodeChatDisbanded      = 230017
+	feishuCodeCardContentInvalid = 230099
+)
+
+// sendError classifies a failed send. Feishu reports when the rate limit
+// window resets in the x-ogw-ratelimit-reset header.
+func (s *FeishuService) sendError(resp *http.Response, code int, msg string) error {
+	switch code {
+	case feishuCodeRateLimited:
+		reset, _ := strconv.Atoi(resp.Header.Get("x-ogw-ratelimit-reset"))
+		return RateLimitedError(time.Duration(reset)*time.Second, "feishu error: %d - %s", code, msg)
+	case feishuCodeInvalidToken, feishuCodeTokenExpired:
+		// Drop the cached token so the retry fetches a new one.
+		s.tokenMu.Lock()
+		s.token = ""
+		s.tokenMu.Unlock()
+		return TransientError("feishu error: %d - %s", code, msg)
+	case feishuCodeInvalidParam, feishuCodeBotNotInChat, feishuCodeBotDisabled,
+		feishuCodeNoAvailability, feishuCodeChatDisbanded, feishuCodeCardContentInvalid:
+		return PermanentError("feishu error: %d - %s", code, msg)
+	}
+
+	if resp.StatusCode == http.StatusOK {
+		return TransientError("feishu error: %d - %s", code, msg)
+	}
+	if code == 0 {
+		return sendStatusError(resp.StatusCode)
+	}
+	return &SendError{Kind: statusErrorKind(resp.StatusCode), Err: fmt.Errorf("feishu error: %d - %s", code, msg)}
+}
+
 func (s *FeishuService) buildCardMessage(params MessageParams) map[string]any {
 	message := map[string]any{
 		"config":   map[string]any{"wide_screen_mode": true},
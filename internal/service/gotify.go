@@ -0,0 +1,88 @@
+package service
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"notify/internal/config"
+)
+
+// gotifyPriorities maps message colors to Gotify priorities, 0 to 10. Clients
+// alert loudly from 8.
+var gotifyPriorities = map[Color]int{
+	ColorBlue:   5,
+	ColorGreen:  5,
+	ColorOrange: 8,
+	ColorGrey:   2,
+	ColorRed:    10,
+	ColorPurple: 5,
+}
+
+type GotifyService struct {
+	serverURL string
+	apps      map[string]string
+	client    *http.Client
+}
+
+func NewGotifyService(cfg config.GotifyConfig) *GotifyService {
+	return &GotifyService{
+		serverURL: strings.TrimSuffix(cfg.ServerURL, "/"),
+		apps:      cfg.Apps,
+		client:    &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+func (s *GotifyService) Channel() Channel {
+	return ChannelGotify
+}
+
+func (s *GotifyService) BuildMessage(params MessageParams) any {
+	return s.buildMessage(params)
+}
+
+func (s *GotifyService) SendMessage(target string, params MessageParams) (*SendResult, error) {
+	return s.SendRawMessage(target, s.buildMessage(params))
+}
+
+// SendRawMessage posts as the application named by target.
+func (s *GotifyService) SendRawMessage(target string, message any) (*SendResult, error) {
+	slog.Info("Sending Gotify message", "target", target)
+
+	token, ok := s.apps[target]
+	if !ok {
+		return nil, PermanentError("gotify: unknown app %q", target)
+	}
+
+	var result struct {
+		ID int64 `json:"id"`
+	}
+	header := http.Header{"X-Gotify-Key": {token}}
+	if err := postPushJSON(s.client, "gotify", s.serverURL+"/message", header, message, token, &result); err != nil {
+		return nil, err
+	}
+	return &SendResult{Success: true, MessageID: strconv.FormatInt(result.ID, 10)}, nil
+}
+
+func (s *GotifyService) buildMessage(params MessageParams) map[string]any {
+	extras := map[string]any{
+		"client::display": map[string]any{"contentType": "text/markdown"},
+	}
+	if params.URL != "" {
+		extras["client::notification"] = map[string]any{"click": map[string]any{"url": params.URL}}
+	}
+
+	message := map[string]any{
+		"message": pushText(params),
+		"extras":  extras,
+	}
+	if params.Title != "" {
+		message["title"] = params.Title
+	}
+	if priority, ok := gotifyPriorities[params.Color]; ok {
+		message["priority"] = priority
+	}
+	return message
+}
@@ -0,0 +1,51 @@
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"notify/internal/config"
+)
+
+func TestGotifySendMessage(t *testing.T) {
+	var gotKey string
+	var gotBody map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotKey = r.Header.Get("X-Gotify-Key")
+		if gotKey != "app-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"error":"Unauthorized","errorCode":401}`))
+			return
+		}
+		_ = json.NewDecoder(r.Body).Decode(&gotBody)
+		_, _ = w.Write([]byte(`{"id":42}`))
+	}))
+	defer srv.Close()
+
+	svc := NewGotifyService(config.GotifyConfig{
+		ServerURL: srv.URL,
+		Apps:      map[string]string{"alerts": "app-token", "revoked": "old-token"},
+	})
+	result, err := svc.SendMessage("alerts", MessageParams{Title: "Deploy", Color: ColorOrange, Content: "done", URL: "https://example.com"})
+	if err != nil {
+		t.Fatalf("SendMessage() error = %v", err)
+	}
+	if result.MessageID != "42" || gotBody["title"] != "Deploy" || gotBody["priority"] != float64(8) {
+		t.Fatalf("result = %+v, body = %v", result, gotBody)
+	}
+	click := gotBody["extras"].(map[string]any)["client::notification"].(map[string]any)["click"].(map[string]any)
+	if click["url"] != "https://example.com" {
+		t.Fatalf("extras = %v", gotBody["extras"])
+	}
+
+	_, err = svc.SendMessage("revoked", MessageParams{Content: "hi"})
+	if kind, _ := ClassifyError(err); kind != ErrorPermanent {
+		t.Fatalf("401 = %v; error = %v", kind, err)
+	}
+	_, err = svc.SendMessage("missing", MessageParams{Content: "hi"})
+	if kind, _ := ClassifyError(err); kind != ErrorPermanent {
+		t.Fatalf("unknown app = %v; error = %v", kind, err)
+	}
+}
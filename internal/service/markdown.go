@@ -0,0 +1,134 @@
+package service
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+)
+
+var (
+	markdownHeading     = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*$`)
+	markdownBullet      = regexp.MustCompile(`^\s*[-*+]\s+(.*)$`)
+	markdownOrdered     = regexp.MustCompile(`^\s*\d+[.)]\s+(.*)$`)
+	markdownRule        = regexp.MustCompile(`^\s*([-*_])(\s*([-*_])){2,}\s*$`)
+	markdownStrong      = regexp.MustCompile(`\*\*(.+?)\*\*|__(.+?)__`)
+	markdownEmphasis    = regexp.MustCompile(`\*([^*\s](?:[^*]*[^*\s])?)\*`)
+	markdownStrike      = regexp.MustCompile(`~~(.+?)~~`)
+	markdownSafeLinkURL = regexp.MustCompile(`^(?i:https?://|mailto:)`)
+)
+
+// markdownToHTML renders the Markdown subset callers use in notifications:
+// headings, paragraphs, lists, quotes, rules, fenced code, and inline code,
+// bold, italic, strikethrough and links. Single newlines are kept as line
+// breaks, as chat clients show them. All text is HTML-escaped.
+func markdownToHTML(src string) string {
+	lines := strings.Split(strings.ReplaceAll(src, "\r\n", "\n"), "\n")
+	var b strings.Builder
+	var paragraph []string
+
+	flush := func() {
+		if len(paragraph) > 0 {
+			b.WriteString("<p>" + strings.Join(paragraph, "<br>\n") + "</p>\n")
+			paragraph = nil
+		}
+	}
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case trimmed == "":
+			flush()
+
+		case strings.HasPrefix(trimmed, "```"):
+			flush()
+			var code []string
+			for i++; i < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[i]), "```"); i++ {
+				code = append(code, html.EscapeString(lines[i]))
+			}
+			b.WriteString("<pre><code>" + strings.Join(code, "\n") + "</code></pre>\n")
+
+		case markdownHeading.MatchString(trimmed):
+			flush()
+			m := markdownHeading.FindStringSubmatch(trimmed)
+			fmt.Fprintf(&b, "<h%d>%s</h%[1]d>\n", len(m[1]), markdownInline(m[2]))
+
+		case markdownRule.MatchString(trimmed):
+			flush()
+			b.WriteString("<hr>\n")
+
+		case strings.HasPrefix(trimmed, ">"):
+			flush()
+			var quoted []string
+			for ; i < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[i]), ">"); i++ {
+				quoted = append(quoted, strings.TrimPrefix(strings.TrimPrefix(strings.TrimSpace(lines[i]), ">"), " "))
+			}
+			i--
+			b.WriteString("<blockquote>\n" + markdownToHTML(strings.Join(quoted, "\n")) + "</blockquote>\n")
+
+		case markdownBullet.MatchString(line), markdownOrdered.MatchString(line):
+			flush()
+			re, tag := markdownBullet, "ul"
+			if !markdownBullet.MatchString(line) {
+				re, tag = markdownOrdered, "ol"
+			}
+			b.WriteString("<" + tag + ">\n")
+			for ; i < len(lines) && re.MatchString(lines[i]); i++ {
+				b.WriteString("<li>" + markdownInline(re.FindStringSubmatch(lines[i])[1]) + "</li>\n")
+			}
+			i--
+			b.WriteString("</" + tag + ">\n")
+
+		default:
+			paragraph = append(paragraph, markdownInline(trimmed))
+		}
+	}
+	flush()
+	return b.String()
+}
+
+// markdownInline escapes text and renders inline markup. Code spans are left
+// as they are, and link URLs are kept out of the emphasis passes.
+func markdownInline(text string) string {
+	segments := strings.Split(text, "`")
+	// An unmatched backtick is literal.
+	if len(segments)%2 == 0 {
+		segments[len(segments)-2] += "`" + segments[len(segments)-1]
+		segments = segments[:len(segments)-1]
+	}
+
+	var b strings.Builder
+	for i, segment := range segments {
+		escaped := html.EscapeString(segment)
+		if i%2 == 1 {
+			b.WriteString("<code>" + escaped + "</code>")
+			continue
+		}
+		// Links become NUL-delimited placeholders until the emphasis passes
+		// are done.
+		escaped = strings.ReplaceAll(escaped, "\x00", "")
+		var links []string
+		escaped = markdownLink.ReplaceAllStringFunc(escaped, func(m string) string {
+			parts := markdownLink.FindStringSubmatch(m)
+			if !markdownSafeLinkURL.MatchString(parts[2]) {
+				return m
+			}
+			links = append(links, `<a href="`+parts[2]+`">`+markdownEmphasize(parts[1])+`</a>`)
+			return fmt.Sprintf("\x00%d\x00", len(links)-1)
+		})
+		escaped = markdownEmphasize(escaped)
+		for n, link := range links {
+			escaped = strings.Replace(escaped, fmt.Sprintf("\x00%d\x00", n), link, 1)
+		}
+		b.WriteString(escaped)
+	}
+	return b.String()
+}
+
+func markdownEmphasize(text string) string {
+	text = markdownStrong.ReplaceAllString(text, "<strong>$1$2</strong>")
+	text = markdownEmphasis.ReplaceAllString(text, "<em>$1</em>")
+	return markdownStrike.ReplaceAllString(text, "<del>$1</del>")
+}
@@ -0,0 +1,232 @@
+package service
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"notify/internal/config"
+)
+
+const matrixClientAPI = "/_matrix/client/v3"
+
+type MatrixService struct {
+	homeserverURL string
+	accessToken   string
+	client        *http.Client
+
+	aliasMu sync.Mutex
+	aliases map[string]string // room alias -> room ID
+}
+
+func NewMatrixService(cfg config.MatrixConfig) *MatrixService {
+	return &MatrixService{
+		homeserverURL: strings.TrimSuffix(cfg.HomeserverURL, "/"),
+		accessToken:   cfg.AccessToken,
+		client:        &http.Client{Timeout: 30 * time.Second},
+		aliases:       make(map[string]string),
+	}
+}
+
+func (s *MatrixService) Channel() Channel {
+	return ChannelMatrix
+}
+
+func (s *MatrixService) BuildMessage(params MessageParams) any {
+	return s.buildMessage(params)
+}
+
+func (s *MatrixService) SendMessage(target string, params MessageParams) (*SendResult, error) {
+	return s.SendRawMessage(target, s.buildMessage(params))
+}
+
+// SendRawMessage sends with a random transaction ID. Queued tasks go through
+// SendRawMessageTxn instead.
+func (s *MatrixService) SendRawMessage(target string, message any) (*SendResult, error) {
+	buf := make([]byte, 16)
+	_, _ = rand.Read(buf)
+	return s.SendRawMessageTxn(target, message, hex.EncodeToString(buf))
+}
+
+// SendRawMessageTxn sends message as an m.room.message event to target, a
+// room ID ("!id:server") or alias ("#alias:server"). The homeserver returns
+// the original event for a repeated txnID instead of sending it again.
+func (s *MatrixService) SendRawMessageTxn(target string, message any, txnID string) (*SendResult, error) {
+	slog.Info("Sending Matrix message", "target", target)
+
+	roomID, err := s.resolveRoom(target)
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		EventID string `json:"event_id"`
+	}
+	path := "/rooms/" + url.PathEscape(roomID) + "/send/m.room.message/" + url.PathEscape(txnID)
+	if err := s.call(http.MethodPut, path, message, &result); err != nil {
+		return nil, err
+	}
+	return &SendResult{Success: true, MessageID: result.EventID}, nil
+}
+
+// resolveRoom returns the room ID for an alias, caching the answer.
+func (s *MatrixService) resolveRoom(target string) (string, error) {
+	if !strings.HasPrefix(target, "#") {
+		return target, nil
+	}
+
+	s.aliasMu.Lock()
+	roomID, ok := s.aliases[target]
+	s.aliasMu.Unlock()
+	if ok {
+		return roomID, nil
+	}
+
+	var result struct {
+		RoomID string `json:"room_id"`
+	}
+	if err := s.call(http.MethodGet, "/directory/room/"+url.PathEscape(target), nil, &result); err != nil {
+		return "", fmt.Errorf("resolve %s: %w", target, err)
+	}
+
+	s.aliasMu.Lock()
+	s.aliases[target] = result.RoomID
+	s.aliasMu.Unlock()
+	return result.RoomID, nil
+}
+
+// call invokes a client-server API endpoint. Errors carry the Matrix errcode;
+// M_LIMIT_EXCEEDED uses the server's retry_after_ms.
+func (s *MatrixService) call(method, path string, payload any, result any) error {
+	var body io.Reader
+	if payload != nil {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return fmt.Errorf("marshal request: %w", err)
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, s.homeserverURL+matrixClientAPI+path, body)
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+s.accessToken)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return TransientError("%s %s: %w", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	data, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		var matrixErr struct {
+			ErrCode      string `json:"errcode"`
+			Error        string `json:"error"`
+			RetryAfterMS int64  `json:"retry_after_ms"`
+		}
+		_ = json.Unmarshal(data, &matrixErr)
+		if resp.StatusCode == http.StatusTooManyRequests {
+			retryAfter := time.Duration(matrixErr.RetryAfterMS) * time.Millisecond
+			if retryAfter <= 0 {
+				retryAfter = retryAfterHeader(resp.Header)
+			}
+			return RateLimitedError(retryAfter, "matrix error: 429 - %s %s", matrixErr.ErrCode, matrixErr.Error)
+		}
+		return statusError(resp, "matrix error: %d - %s %s", resp.StatusCode, matrixErr.ErrCode, matrixErr.Error)
+	}
+
+	if result != nil {
+		if err := json.Unmarshal(data, result); err != nil {
+			return TransientError("decode response: %w", err)
+		}
+	}
+	return nil
+}
+
+// ListChats lists the rooms the account has joined, with their names and
+// topics where set.
+func (s *MatrixService) ListChats() ([]ChatItem, error) {
+	var joined struct {
+		JoinedRooms []string `json:"joined_rooms"`
+	}
+	if err := s.call(http.MethodGet, "/joined_rooms", nil, &joined); err != nil {
+		return nil, fmt.Errorf("list joined rooms: %w", err)
+	}
+
+	chats := make([]ChatItem, len(joined.JoinedRooms))
+	for i, roomID := range joined.JoinedRooms {
+		chats[i] = ChatItem{ChatID: roomID, Name: roomID}
+
+		// Rooms without a name or topic answer 404; those fields stay empty.
+		var name struct {
+			Name string `json:"name"`
+		}
+		if err := s.call(http.MethodGet, "/rooms/"+url.PathEscape(roomID)+"/state/m.room.name", nil, &name); err == nil && name.Name != "" {
+			chats[i].Name = name.Name
+		}
+		var topic struct {
+			Topic string `json:"topic"`
+		}
+		if err := s.call(http.MethodGet, "/rooms/"+url.PathEscape(roomID)+"/state/m.room.topic", nil, &topic); err == nil {
+			chats[i].Description = topic.Topic
+		}
+	}
+	return chats, nil
+}
+
+// CheckHealth confirms the access token with whoami.
+func (s *MatrixService) CheckHealth() error {
+	return s.call(http.MethodGet, "/account/whoami", nil, nil)
+}
+
+// buildMessage renders params as an m.notice, the message type for bots,
+// with a plain body and an HTML formatted_body: the title as a colored
+// heading, the content rendered from Markdown, a link and the note.
+func (s *MatrixService) buildMessage(params MessageParams) map[string]any {
+	var text []string
+	for _, part := range []string{params.Title, params.Content, params.URL, params.Note} {
+		if part != "" {
+			text = append(text, part)
+		}
+	}
+
+	var b strings.Builder
+	if params.Title != "" {
+		title := html.EscapeString(params.Title)
+		if color := colorHex(params.Color); color != "" {
+			title = fmt.Sprintf(`<font data-mx-color="%s">%s</font>`, color, title)
+		}
+		b.WriteString("<h4>" + title + "</h4>\n")
+	}
+	if params.Content != "" {
+		b.WriteString(markdownToHTML(params.Content))
+	}
+	if params.URL != "" && markdownSafeLinkURL.MatchString(params.URL) {
+		fmt.Fprintf(&b, "<p><a href=\"%s\">View Details</a></p>\n", html.EscapeString(params.URL))
+	}
+	if params.Note != "" {
+		b.WriteString("<p><em>" + strings.ReplaceAll(html.EscapeString(params.Note), "\n", "<br>") + "</em></p>\n")
+	}
+
+	return map[string]any{
+		"msgtype":        "m.notice",
+		"body":           strings.Join(text, "\n\n"),
+		"format":         "org.matrix.custom.html",
+		"formatted_body": strings.TrimSuffix(b.String(), "\n"),
+	}
+}
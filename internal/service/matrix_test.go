@@ -0,0 +1,115 @@
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"notify/internal/config"
+)
+
+func TestMatrixSendRawMessageTxn(t *testing.T) {
+	var aliasLookups int
+	var gotPath, gotAuth string
+	var gotBody map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := strings.TrimPrefix(r.URL.EscapedPath(), "/_matrix/client/v3")
+		switch {
+		case path == "/directory/room/%23ops:example.org":
+			aliasLookups++
+			_, _ = w.Write([]byte(`{"room_id":"!ops:example.org"}`))
+		case strings.HasPrefix(path, "/rooms/%21busy:example.org/"):
+			w.WriteHeader(http.StatusTooManyRequests)
+			_, _ = w.Write([]byte(`{"errcode":"M_LIMIT_EXCEEDED","error":"Too Many Requests","retry_after_ms":2500}`))
+		case strings.HasPrefix(path, "/rooms/%21private:example.org/"):
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"errcode":"M_FORBIDDEN","error":"not in room"}`))
+		default:
+			gotPath, gotAuth = path, r.Header.Get("Authorization")
+			_ = json.NewDecoder(r.Body).Decode(&gotBody)
+			_, _ = w.Write([]byte(`{"event_id":"$event1"}`))
+		}
+	}))
+	defer srv.Close()
+
+	svc := NewMatrixService(config.MatrixConfig{HomeserverURL: srv.URL, AccessToken: "syt_token"})
+	message := svc.BuildMessage(MessageParams{Content: "hi"})
+
+	for range 2 {
+		result, err := svc.SendRawMessageTxn("#ops:example.org", message, "task_1_1")
+		if err != nil {
+			t.Fatalf("SendRawMessageTxn() error = %v", err)
+		}
+		if result.MessageID != "$event1" {
+			t.Fatalf("MessageID = %q", result.MessageID)
+		}
+	}
+	if aliasLookups != 1 {
+		t.Fatalf("alias lookups = %d, want cached", aliasLookups)
+	}
+	if gotPath != "/rooms/%21ops:example.org/send/m.room.message/task_1_1" || gotAuth != "Bearer syt_token" ||
+		gotBody["msgtype"] != "m.notice" {
+		t.Fatalf("request = %s %q %v", gotPath, gotAuth, gotBody)
+	}
+
+	_, err := svc.SendRawMessageTxn("!busy:example.org", message, "task_2_1")
+	if kind, retryAfter := ClassifyError(err); kind != ErrorRateLimited || retryAfter != 2500*time.Millisecond {
+		t.Fatalf("429 = %v, %s; error = %v", kind, retryAfter, err)
+	}
+	_, err = svc.SendRawMessageTxn("!private:example.org", message, "task_3_1")
+	if kind, _ := ClassifyError(err); kind != ErrorPermanent {
+		t.Fatalf("403 = %v; error = %v", kind, err)
+	}
+}
+
+func TestMatrixListChats(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch strings.TrimPrefix(r.URL.EscapedPath(), "/_matrix/client/v3") {
+		case "/joined_rooms":
+			_, _ = w.Write([]byte(`{"joined_rooms":["!a:example.org","!b:example.org"]}`))
+		case "/rooms/%21a:example.org/state/m.room.name":
+			_, _ = w.Write([]byte(`{"name":"Ops"}`))
+		case "/rooms/%21a:example.org/state/m.room.topic":
+			_, _ = w.Write([]byte(`{"topic":"Alerts"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"errcode":"M_NOT_FOUND","error":"Event not found."}`))
+		}
+	}))
+	defer srv.Close()
+
+	chats, err := NewMatrixService(config.MatrixConfig{HomeserverURL: srv.URL, AccessToken: "t"}).ListChats()
+	if err != nil {
+		t.Fatalf("ListChats() error = %v", err)
+	}
+	want := []ChatItem{
+		{ChatID: "!a:example.org", Name: "Ops", Description: "Alerts"},
+		{ChatID: "!b:example.org", Name: "!b:example.org"},
+	}
+	if !reflect.DeepEqual(chats, want) {
+		t.Fatalf("ListChats() = %v", chats)
+	}
+}
+
+func TestMatrixBuildMessage(t *testing.T) {
+	svc := NewMatrixService(config.MatrixConfig{})
+	message := svc.buildMessage(MessageParams{
+		Title: "Disk <full>", Color: ColorRed, Content: "**host-1**", URL: "https://example.com", Note: "runbook",
+	})
+	want := map[string]any{
+		"msgtype": "m.notice",
+		"body":    "Disk <full>\n\n**host-1**\n\nhttps://example.com\n\nrunbook",
+		"format":  "org.matrix.custom.html",
+		"formatted_body": "<h4><font data-mx-color=\"#E01E5A\">Disk &lt;full&gt;</font></h4>\n" +
+			"<p><strong>host-1</strong></p>\n" +
+			"<p><a href=\"https://example.com\">View Details</a></p>\n" +
+			"<p><em>runbook</em></p>",
+	}
+	if !reflect.DeepEqual(message, want) {
+		t.Fatalf("buildMessage() = %#v", message)
+	}
+}
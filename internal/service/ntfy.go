@@ -0,0 +1,106 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"notify/internal/config"
+)
+
+// ntfyPriorities maps message colors to ntfy priorities, 1 (min) to 5 (urgent).
+var ntfyPriorities = map[Color]int{
+	ColorBlue:   3,
+	ColorGreen:  3,
+	ColorOrange: 4,
+	ColorGrey:   2,
+	ColorRed:    5,
+	ColorPurple: 3,
+}
+
+type NtfyService struct {
+	serverURL string
+	token     string
+	client    *http.Client
+}
+
+func NewNtfyService(cfg config.NtfyConfig) *NtfyService {
+	return &NtfyService{
+		serverURL: strings.TrimSuffix(cfg.ServerURL, "/"),
+		token:     cfg.Token,
+		client:    &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+func (s *NtfyService) Channel() Channel {
+	return ChannelNtfy
+}
+
+func (s *NtfyService) BuildMessage(params MessageParams) any {
+	return s.buildMessage(params)
+}
+
+func (s *NtfyService) SendMessage(target string, params MessageParams) (*SendResult, error) {
+	return s.SendRawMessage(target, s.buildMessage(params))
+}
+
+// SendRawMessage publishes to the topic target.
+func (s *NtfyService) SendRawMessage(target string, message any) (*SendResult, error) {
+	slog.Info("Sending ntfy message", "target", target)
+
+	header := http.Header{}
+	if s.token != "" {
+		header.Set("Authorization", "Bearer "+s.token)
+	}
+
+	var result struct {
+		ID string `json:"id"`
+	}
+	if err := postPushJSON(s.client, "ntfy", s.serverURL+"/", header, withField(message, "topic", target), s.token, &result); err != nil {
+		return nil, err
+	}
+	return &SendResult{Success: true, MessageID: result.ID}, nil
+}
+
+// CheckHealth queries the server's health endpoint.
+func (s *NtfyService) CheckHealth() error {
+	resp, err := s.client.Get(s.serverURL + "/v1/health")
+	if err != nil {
+		return fmt.Errorf("get health: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Healthy bool `json:"healthy"`
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("get health: unexpected status: %d", resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+	if !result.Healthy {
+		return fmt.Errorf("server reports unhealthy")
+	}
+	return nil
+}
+
+func (s *NtfyService) buildMessage(params MessageParams) map[string]any {
+	message := map[string]any{
+		"message":  pushText(params),
+		"markdown": true,
+	}
+	if params.Title != "" {
+		message["title"] = params.Title
+	}
+	if params.URL != "" {
+		message["click"] = params.URL
+	}
+	if priority, ok := ntfyPriorities[params.Color]; ok {
+		message["priority"] = priority
+	}
+	return message
+}
@@ -0,0 +1,53 @@
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"notify/internal/config"
+)
+
+func TestNtfySendMessage(t *testing.T) {
+	var gotAuth string
+	var gotBody map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/health" {
+			_, _ = w.Write([]byte(`{"healthy":true}`))
+			return
+		}
+		gotAuth = r.Header.Get("Authorization")
+		_ = json.NewDecoder(r.Body).Decode(&gotBody)
+		if gotBody["topic"] == "limited" {
+			w.Header().Set("Retry-After", "30")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		_, _ = w.Write([]byte(`{"id":"msg-1","topic":"oncall"}`))
+	}))
+	defer srv.Close()
+
+	svc := NewNtfyService(config.NtfyConfig{ServerURL: srv.URL + "/", Token: "tk_test"})
+	result, err := svc.SendMessage("oncall", MessageParams{
+		Title: "Disk full", Color: ColorRed, Content: "host-1", Note: "runbook", URL: "https://example.com",
+	})
+	if err != nil {
+		t.Fatalf("SendMessage() error = %v", err)
+	}
+	if result.MessageID != "msg-1" || gotAuth != "Bearer tk_test" {
+		t.Fatalf("result = %+v, auth = %q", result, gotAuth)
+	}
+	if gotBody["topic"] != "oncall" || gotBody["title"] != "Disk full" || gotBody["message"] != "host-1\n\nrunbook" ||
+		gotBody["click"] != "https://example.com" || gotBody["priority"] != float64(5) {
+		t.Fatalf("body = %v", gotBody)
+	}
+
+	_, err = svc.SendMessage("limited", MessageParams{Content: "hi"})
+	if kind, _ := ClassifyError(err); kind != ErrorRateLimited {
+		t.Fatalf("429 = %v; error = %v", kind, err)
+	}
+	if err := svc.CheckHealth(); err != nil {
+		t.Fatalf("CheckHealth() error = %v", err)
+	}
+}
@@ -0,0 +1,71 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// pushText joins content and note into the body of a push notification.
+// Push clients show a title and a body only.
+func pushText(params MessageParams) string {
+	var parts []string
+	for _, part := range []string{params.Content, params.Note} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// withField copies a raw message and sets key, so targets can be merged into
+// caller-built payloads.
+func withField(message any, key string, value any) map[string]any {
+	payload := map[string]any{}
+	if m, ok := message.(map[string]any); ok {
+		for k, v := range m {
+			payload[k] = v
+		}
+	}
+	payload[key] = value
+	return payload
+}
+
+// postPushJSON posts payload to endpoint and decodes a 2xx response into
+// result. Other responses are classified by status; secret is removed from
+// transport errors.
+func postPushJSON(client *http.Client, name, endpoint string, header http.Header, payload any, secret string, result any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("marshal message: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("create request: %w", redactURLError(err, secret))
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return TransientError("send message: %w", redactURLError(err, secret))
+	}
+	defer resp.Body.Close()
+
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return statusError(resp, "%s error: %d - %s", name, resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+	if result != nil {
+		if err := json.Unmarshal(data, result); err != nil {
+			return TransientError("decode response: %w", err)
+		}
+	}
+	return nil
+}
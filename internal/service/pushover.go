@@ -0,0 +1,105 @@
+package service
+
+import (
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"notify/internal/config"
+)
+
+// pushoverPriorities maps message colors to Pushover priorities, -2 to 2.
+var pushoverPriorities = map[Color]int{
+	ColorBlue:   0,
+	ColorGreen:  0,
+	ColorOrange: 1,
+	ColorGrey:   -1,
+	ColorRed:    2,
+	ColorPurple: 0,
+}
+
+// Emergency notifications repeat every pushoverRetry until acknowledged or
+// pushoverExpire passes.
+const (
+	pushoverEmergency = 2
+	pushoverRetry     = 60
+	pushoverExpire    = 3600
+)
+
+type PushoverService struct {
+	appToken string
+	apiURL   string
+	client   *http.Client
+}
+
+func NewPushoverService(cfg config.PushoverConfig) *PushoverService {
+	return &PushoverService{
+		appToken: cfg.AppToken,
+		apiURL:   strings.TrimSuffix(cfg.APIURL, "/"),
+		client:   &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+func (s *PushoverService) Channel() Channel {
+	return ChannelPushover
+}
+
+func (s *PushoverService) BuildMessage(params MessageParams) any {
+	return s.buildMessage(params)
+}
+
+func (s *PushoverService) SendMessage(target string, params MessageParams) (*SendResult, error) {
+	return s.SendRawMessage(target, s.buildMessage(params))
+}
+
+// SendRawMessage pushes to the user or group key target. A "key:device"
+// target limits delivery to one of the user's devices.
+func (s *PushoverService) SendRawMessage(target string, message any) (*SendResult, error) {
+	slog.Info("Sending Pushover message", "target", target)
+
+	user, device, _ := strings.Cut(target, ":")
+	payload := withField(message, "user", user)
+	payload["token"] = s.appToken
+	if device != "" {
+		payload["device"] = device
+	}
+
+	// Emergency notifications return a receipt for tracking acknowledgement.
+	var result struct {
+		Request string `json:"request"`
+		Receipt string `json:"receipt"`
+	}
+	if err := postPushJSON(s.client, "pushover", s.apiURL+"/1/messages.json", nil, payload, s.appToken, &result); err != nil {
+		return nil, err
+	}
+	messageID := result.Receipt
+	if messageID == "" {
+		messageID = result.Request
+	}
+	return &SendResult{Success: true, MessageID: messageID}, nil
+}
+
+func (s *PushoverService) buildMessage(params MessageParams) map[string]any {
+	text := pushText(params)
+	if text == "" {
+		// Pushover rejects empty messages.
+		text = params.Title
+	}
+
+	message := map[string]any{"message": text}
+	if params.Title != "" {
+		message["title"] = params.Title
+	}
+	if params.URL != "" {
+		message["url"] = params.URL
+	}
+	if priority, ok := pushoverPriorities[params.Color]; ok {
+		message["priority"] = priority
+		if priority == pushoverEmergency {
+			message["retry"] = pushoverRetry
+			message["expire"] = pushoverExpire
+		}
+	}
+	return message
+}
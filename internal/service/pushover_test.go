@@ -0,0 +1,56 @@
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"notify/internal/config"
+)
+
+func TestPushoverSendMessage(t *testing.T) {
+	var gotBody map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotBody = nil
+		_ = json.NewDecoder(r.Body).Decode(&gotBody)
+		if gotBody["user"] == "invalid" {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"user":"invalid","errors":["user identifier is invalid"],"status":0}`))
+			return
+		}
+		if gotBody["priority"] == float64(2) {
+			_, _ = w.Write([]byte(`{"status":1,"request":"req-1","receipt":"rcpt-1"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"status":1,"request":"req-1"}`))
+	}))
+	defer srv.Close()
+
+	svc := NewPushoverService(config.PushoverConfig{AppToken: "app-token", APIURL: srv.URL})
+
+	result, err := svc.SendMessage("user-key:phone", MessageParams{Title: "Disk full", Color: ColorRed, Content: "host-1"})
+	if err != nil {
+		t.Fatalf("SendMessage() error = %v", err)
+	}
+	if result.MessageID != "rcpt-1" || gotBody["token"] != "app-token" || gotBody["user"] != "user-key" ||
+		gotBody["device"] != "phone" || gotBody["retry"] != float64(pushoverRetry) || gotBody["expire"] != float64(pushoverExpire) {
+		t.Fatalf("result = %+v, body = %v", result, gotBody)
+	}
+
+	result, err = svc.SendMessage("user-key", MessageParams{Title: "Deploy", Color: ColorGreen})
+	if err != nil {
+		t.Fatalf("SendMessage() error = %v", err)
+	}
+	if result.MessageID != "req-1" || gotBody["message"] != "Deploy" || gotBody["priority"] != float64(0) {
+		t.Fatalf("result = %+v, body = %v", result, gotBody)
+	}
+	if _, ok := gotBody["device"]; ok {
+		t.Fatalf("body = %v", gotBody)
+	}
+
+	_, err = svc.SendMessage("invalid", MessageParams{Content: "hi"})
+	if kind, _ := ClassifyError(err); kind != ErrorPermanent {
+		t.Fatalf("400 = %v; error = %v", kind, err)
+	}
+}
@@ -2,6 +2,7 @@ package service
 
 import (
 	"fmt"
+	"sort"
 
 	"notify/internal/config"
 )
@@ -17,13 +18,81 @@ type ChatLister interface {
 	ListChats() ([]ChatItem, error)
 }
 
+// HealthChecker is implemented by services that can verify their
+// credentials against the platform.
+type HealthChecker interface {
+	CheckHealth() error
+}
+
+// TransactionalSender is implemented by services whose API deduplicates
+// requests by a client transaction ID. The queue passes the task ID, so a
+// retry after a lost response cannot post the message twice.
+type TransactionalSender interface {
+	SendRawMessageTxn(target string, message any, txnID string) (*SendResult, error)
+}
+
 var services map[Channel]NotifyService
 
-func Init(cfg *config.Config) {
-	services = map[Channel]NotifyService{
-		ChannelFeishu:   NewFeishuService(cfg.Feishu),
-		ChannelTelegram: NewTelegramService(cfg.Telegram),
+// Init registers the services that have credentials configured.
+func Init(cfg *config.Config) error {
+	services = make(map[Channel]NotifyService)
+	if cfg.Feishu.AppID != "" {
+		services[ChannelFeishu] = NewFeishuService(cfg.Feishu)
+	}
+	if cfg.Telegram.BotToken != "" {
+		services[ChannelTelegram] = NewTelegramService(cfg.Telegram)
+	}
+	if cfg.Slack.Enabled() {
+		services[ChannelSlack] = NewSlackService(cfg.Slack)
+	}
+	if cfg.Discord.Enabled() {
+		services[ChannelDiscord] = NewDiscordService(cfg.Discord)
+	}
+	if len(cfg.DingTalk.Robots) > 0 {
+		services[ChannelDingTalk] = NewDingTalkService(cfg.DingTalk)
+	}
+	if cfg.WeCom.Enabled() {
+		services[ChannelWeCom] = NewWeComService(cfg.WeCom)
+	}
+	if cfg.Email.Enabled() {
+		services[ChannelEmail] = NewEmailService(cfg.Email)
+	}
+	if cfg.Teams.Enabled() {
+		services[ChannelTeams] = NewTeamsService(cfg.Teams)
+	}
+	if cfg.Ntfy.ServerURL != "" {
+		services[ChannelNtfy] = NewNtfyService(cfg.Ntfy)
+	}
+	if len(cfg.Gotify.Apps) > 0 {
+		services[ChannelGotify] = NewGotifyService(cfg.Gotify)
+	}
+	if cfg.Bark.Devices != nil {
+		services[ChannelBark] = NewBarkService(cfg.Bark)
+	}
+	if cfg.Pushover.AppToken != "" {
+		services[ChannelPushover] = NewPushoverService(cfg.Pushover)
+	}
+	if cfg.Matrix.AccessToken != "" {
+		services[ChannelMatrix] = NewMatrixService(cfg.Matrix)
+	}
+	if len(cfg.Outbound.Endpoints) > 0 {
+		svc, err := NewWebhookService(cfg.Outbound)
+		if err != nil {
+			return err
+		}
+		services[ChannelWebhook] = svc
+	}
+	return nil
+}
+
+// Services returns the registered services ordered by channel.
+func Services() []NotifyService {
+	list := make([]NotifyService, 0, len(services))
+	for _, svc := range services {
+		list = append(list, svc)
 	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Channel() < list[j].Channel() })
+	return list
 }
 
 func GetService(channel Channel) (NotifyService, error) {
@@ -37,7 +106,9 @@ func GetService(channel Channel) (NotifyService, error) {
 func ValidateChannel(s string) (Channel, error) {
 	channel := Channel(s)
 	switch channel {
-	case ChannelFeishu, ChannelTelegram:
+	case ChannelFeishu, ChannelTelegram, ChannelSlack, ChannelDiscord, ChannelDingTalk, ChannelWeCom,
+		ChannelEmail, ChannelTeams, ChannelWebhook, ChannelNtfy, ChannelGotify, ChannelBark, ChannelPushover,
+		ChannelMatrix:
 		return channel, nil
 	default:
 		return "", fmt.Errorf("invalid channel: %s", s)
@@ -3,7 +3,10 @@ package service
 import "testing"
 
 func TestValidateChannelRequiresCanonicalName(t *testing.T) {
-	for _, name := range []string{"feishu", "telegram"} {
+	for _, name := range []string{
+		"feishu", "telegram", "slack", "discord", "dingtalk", "wecom", "email", "teams", "webhook",
+		"ntfy", "gotify", "bark", "pushover", "matrix",
+	} {
 		channel, err := ValidateChannel(name)
 		if err != nil {
 			t.Fatalf("ValidateChannel(%q) error = %v", name, err)
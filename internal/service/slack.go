@@ -0,0 +1,304 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"notify/internal/config"
+)
+
+// Slack Web API errors that will not recover on retry.
+var slackPermanentErrors = map[string]bool{
+	"channel_not_found":   true,
+	"not_in_channel":      true,
+	"is_archived":         true,
+	"msg_too_long":        true,
+	"no_text":             true,
+	"invalid_blocks":      true,
+	"invalid_attachments": true,
+	"invalid_arguments":   true,
+	"invalid_auth":        true,
+	"not_authed":          true,
+	"account_inactive":    true,
+	"token_revoked":       true,
+	"missing_scope":       true,
+	"restricted_action":   true,
+}
+
+type SlackService struct {
+	botToken string
+	webhooks map[string]string
+	apiURL   string
+	client   *http.Client
+}
+
+func NewSlackService(cfg config.SlackConfig) *SlackService {
+	return &SlackService{
+		botToken: cfg.BotToken,
+		webhooks: cfg.Webhooks,
+		apiURL:   strings.TrimSuffix(cfg.APIURL, "/"),
+		client:   &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+func (s *SlackService) Channel() Channel {
+	return ChannelSlack
+}
+
+func (s *SlackService) BuildMessage(params MessageParams) any {
+	return s.buildBlockMessage(params)
+}
+
+func (s *SlackService) SendMessage(target string, params MessageParams) (*SendResult, error) {
+	return s.SendRawMessage(target, s.buildBlockMessage(params))
+}
+
+// SendRawMessage posts to the incoming webhook named by target, or otherwise
+// to the channel ID target via chat.postMessage. A "channel:thread_ts" target
+// replies in a thread.
+func (s *SlackService) SendRawMessage(target string, message any) (*SendResult, error) {
+	slog.Info("Sending Slack message", "target", target)
+
+	if webhookURL, ok := s.webhooks[target]; ok {
+		return s.sendWebhook(webhookURL, message)
+	}
+	if s.botToken == "" {
+		return nil, PermanentError("slack: no webhook named %q and no bot token configured", target)
+	}
+
+	payload := map[string]any{}
+	if m, ok := message.(map[string]any); ok {
+		for k, v := range m {
+			payload[k] = v
+		}
+	}
+	channel, threadTS, _ := strings.Cut(target, ":")
+	payload["channel"] = channel
+	if threadTS != "" {
+		payload["thread_ts"] = threadTS
+	}
+
+	var result struct {
+		TS string `json:"ts"`
+	}
+	if err := s.call(http.MethodPost, "chat.postMessage", payload, &result); err != nil {
+		return nil, err
+	}
+	return &SendResult{Success: true, MessageID: result.TS}, nil
+}
+
+// sendWebhook posts to an incoming webhook, which answers with plain text.
+func (s *SlackService) sendWebhook(webhookURL string, message any) (*SendResult, error) {
+	body, err := json.Marshal(message)
+	if err != nil {
+		return nil, fmt.Errorf("marshal message: %w", err)
+	}
+
+	resp, err := s.client.Post(webhookURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return nil, TransientError("send webhook: %w", redactURLError(err, webhookURL))
+	}
+	defer resp.Body.Close()
+
+	text, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	if resp.StatusCode != http.StatusOK {
+		return nil, statusError(resp, "slack webhook error: %d - %s", resp.StatusCode, strings.TrimSpace(string(text)))
+	}
+	return &SendResult{Success: true}, nil
+}
+
+// call invokes a Web API method and decodes the response into result. Slack
+// reports most failures as 200 with ok=false.
+func (s *SlackService) call(method, apiMethod string, payload any, result any) error {
+	var body io.Reader
+	endpoint := s.apiURL + "/" + apiMethod
+	if method == http.MethodGet {
+		if query, ok := payload.(url.Values); ok {
+			endpoint += "?" + query.Encode()
+		}
+	} else {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return fmt.Errorf("marshal request: %w", err)
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, endpoint, body)
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+s.botToken)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return TransientError("%s: %w", apiMethod, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return statusError(resp, "slack error: %s: unexpected status: %d", apiMethod, resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return TransientError("read response: %w", err)
+	}
+	var status struct {
+		OK    bool   `json:"ok"`
+		Error string `json:"error"`
+	}
+	if err := json.Unmarshal(data, &status); err != nil {
+		return TransientError("decode response: %w", err)
+	}
+	if !status.OK {
+		return slackError(apiMethod, status.Error)
+	}
+	if result != nil {
+		if err := json.Unmarshal(data, result); err != nil {
+			return TransientError("decode response: %w", err)
+		}
+	}
+	return nil
+}
+
+func slackError(apiMethod, code string) error {
+	switch {
+	case code == "ratelimited":
+		return RateLimitedError(0, "slack error: %s: %s", apiMethod, code)
+	case slackPermanentErrors[code]:
+		return PermanentError("slack error: %s: %s", apiMethod, code)
+	default:
+		return TransientError("slack error: %s: %s", apiMethod, code)
+	}
+}
+
+// ListChats lists the channels the bot can see via conversations.list.
+func (s *SlackService) ListChats() ([]ChatItem, error) {
+	if s.botToken == "" {
+		return nil, fmt.Errorf("listing chats requires APP_SLACK_BOT_TOKEN")
+	}
+
+	var chats []ChatItem
+	cursor := ""
+	for {
+		query := url.Values{
+			"types":            {"public_channel,private_channel"},
+			"exclude_archived": {"true"},
+			"limit":            {"200"},
+		}
+		if cursor != "" {
+			query.Set("cursor", cursor)
+		}
+
+		var result struct {
+			Channels []struct {
+				ID      string `json:"id"`
+				Name    string `json:"name"`
+				Purpose struct {
+					Value string `json:"value"`
+				} `json:"purpose"`
+			} `json:"channels"`
+			ResponseMetadata struct {
+				NextCursor string `json:"next_cursor"`
+			} `json:"response_metadata"`
+		}
+		if err := s.call(http.MethodGet, "conversations.list", query, &result); err != nil {
+			return nil, err
+		}
+
+		for _, ch := range result.Channels {
+			chats = append(chats, ChatItem{ChatID: ch.ID, Name: ch.Name, Description: ch.Purpose.Value})
+		}
+		cursor = result.ResponseMetadata.NextCursor
+		if cursor == "" {
+			return chats, nil
+		}
+	}
+}
+
+// CheckHealth confirms the bot token with auth.test. Incoming webhooks cannot
+// be checked without posting a message.
+func (s *SlackService) CheckHealth() error {
+	if s.botToken == "" {
+		return nil
+	}
+	return s.call(http.MethodPost, "auth.test", map[string]any{}, nil)
+}
+
+// buildBlockMessage renders params as Block Kit. With a color the blocks go
+// inside an attachment, the only way to show a color bar.
+func (s *SlackService) buildBlockMessage(params MessageParams) map[string]any {
+	var blocks []any
+
+	if params.Title != "" {
+		blocks = append(blocks, map[string]any{
+			"type": "header",
+			"text": map[string]any{"type": "plain_text", "text": params.Title, "emoji": true},
+		})
+	}
+
+	if params.Content != "" {
+		blocks = append(blocks, map[string]any{
+			"type": "section",
+			"text": map[string]any{"type": "mrkdwn", "text": slackMarkdown(params.Content)},
+		})
+	}
+
+	if params.URL != "" {
+		blocks = append(blocks, map[string]any{
+			"type": "actions",
+			"elements": []any{map[string]any{
+				"type": "button",
+				"text": map[string]any{"type": "plain_text", "text": "View Details"},
+				"url":  params.URL,
+			}},
+		})
+	}
+
+	if params.Note != "" {
+		blocks = append(blocks, map[string]any{
+			"type":     "context",
+			"elements": []any{map[string]any{"type": "mrkdwn", "text": slackMarkdown(params.Note)}},
+		})
+	}
+
+	// text is the notification fallback when blocks are present.
+	message := map[string]any{"text": slackFallback(params)}
+	if color := colorHex(params.Color); color != "" {
+		message["attachments"] = []any{map[string]any{"color": color, "blocks": blocks}}
+	} else {
+		message["blocks"] = blocks
+	}
+	return message
+}
+
+func slackFallback(params MessageParams) string {
+	if params.Title != "" {
+		return params.Title
+	}
+	return params.Content
+}
+
+var (
+	markdownBold = regexp.MustCompile(`\*\*(.+?)\*\*`)
+	markdownLink = regexp.MustCompile(`\[([^\]]+)\]\(([^)\s]+)\)`)
+)
+
+// slackMarkdown converts the common Markdown used by callers to Slack mrkdwn.
+func slackMarkdown(text string) string {
+	text = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(text)
+	text = markdownBold.ReplaceAllString(text, "*$1*")
+	return markdownLink.ReplaceAllString(text, "<$2|$1>")
+}
@@ -0,0 +1,115 @@
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"notify/internal/config"
+)
+
+func TestSlackSendRawMessage(t *testing.T) {
+	var posted map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/chat.postMessage":
+			if got := r.Header.Get("Authorization"); got != "Bearer xoxb-test" {
+				t.Errorf("Authorization = %q", got)
+			}
+			_ = json.NewDecoder(r.Body).Decode(&posted)
+			if posted["channel"] == "C404" {
+				_, _ = w.Write([]byte(`{"ok":false,"error":"channel_not_found"}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"ok":true,"channel":"C123","ts":"1700000000.000100"}`))
+		case "/hooks/alerts":
+			w.Header().Set("Retry-After", "7")
+			w.WriteHeader(http.StatusTooManyRequests)
+			_, _ = w.Write([]byte("rate_limited"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	svc := NewSlackService(config.SlackConfig{
+		BotToken: "xoxb-test",
+		Webhooks: map[string]string{"alerts": srv.URL + "/hooks/alerts"},
+		APIURL:   srv.URL + "/api",
+	})
+
+	result, err := svc.SendRawMessage("C123:1699999999.000200", map[string]any{"text": "hi"})
+	if err != nil {
+		t.Fatalf("SendRawMessage() error = %v", err)
+	}
+	if result.MessageID != "1700000000.000100" {
+		t.Fatalf("MessageID = %q", result.MessageID)
+	}
+	if posted["channel"] != "C123" || posted["thread_ts"] != "1699999999.000200" || posted["text"] != "hi" {
+		t.Fatalf("posted = %#v", posted)
+	}
+
+	_, err = svc.SendRawMessage("C404", map[string]any{"text": "hi"})
+	if kind, _ := ClassifyError(err); kind != ErrorPermanent {
+		t.Fatalf("channel_not_found kind = %v, error = %v", kind, err)
+	}
+
+	_, err = svc.SendRawMessage("alerts", map[string]any{"text": "hi"})
+	if kind, retryAfter := ClassifyError(err); kind != ErrorRateLimited || retryAfter != 7*time.Second {
+		t.Fatalf("webhook 429 = %v, %s; error = %v", kind, retryAfter, err)
+	}
+}
+
+func TestSlackListChatsPaginates(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("cursor") == "" {
+			_, _ = w.Write([]byte(`{"ok":true,"channels":[{"id":"C1","name":"alerts","purpose":{"value":"Alerts"}}],"response_metadata":{"next_cursor":"next"}}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"ok":true,"channels":[{"id":"C2","name":"deploys"}],"response_metadata":{"next_cursor":""}}`))
+	}))
+	defer srv.Close()
+
+	svc := NewSlackService(config.SlackConfig{BotToken: "xoxb-test", APIURL: srv.URL})
+	chats, err := svc.ListChats()
+	if err != nil {
+		t.Fatalf("ListChats() error = %v", err)
+	}
+	want := []ChatItem{{ChatID: "C1", Name: "alerts", Description: "Alerts"}, {ChatID: "C2", Name: "deploys"}}
+	if !reflect.DeepEqual(chats, want) {
+		t.Fatalf("ListChats() = %#v, want %#v", chats, want)
+	}
+}
+
+func TestSlackBuildMessage(t *testing.T) {
+	svc := NewSlackService(config.SlackConfig{})
+	got := svc.BuildMessage(MessageParams{
+		Title:   "Deploy",
+		Color:   ColorRed,
+		Content: "**failed** see [logs](https://ci/1)",
+		URL:     "https://ci/1",
+		Note:    "prod",
+	}).(map[string]any)
+
+	attachments := got["attachments"].([]any)
+	attachment := attachments[0].(map[string]any)
+	if attachment["color"] != "#E01E5A" {
+		t.Fatalf("color = %v", attachment["color"])
+	}
+
+	var types []string
+	for _, block := range attachment["blocks"].([]any) {
+		types = append(types, block.(map[string]any)["type"].(string))
+	}
+	if want := []string{"header", "section", "actions", "context"}; !reflect.DeepEqual(types, want) {
+		t.Fatalf("block types = %v, want %v", types, want)
+	}
+
+	section := attachment["blocks"].([]any)[1].(map[string]any)["text"].(map[string]any)
+	if section["text"] != "*failed* see <https://ci/1|logs>" {
+		t.Fatalf("section text = %q", section["text"])
+	}
+}
@@ -0,0 +1,177 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"notify/internal/config"
+)
+
+// teamsTextColors maps message colors to Adaptive Card TextBlock colors.
+var teamsTextColors = map[Color]string{
+	ColorBlue:   "accent",
+	ColorGreen:  "good",
+	ColorOrange: "warning",
+	ColorGrey:   "default",
+	ColorRed:    "attention",
+	ColorPurple: "accent",
+}
+
+type TeamsService struct {
+	webhooks map[string]string
+	client   *http.Client
+}
+
+func NewTeamsService(cfg config.TeamsConfig) *TeamsService {
+	return &TeamsService{
+		webhooks: cfg.Webhooks,
+		client:   &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+func (s *TeamsService) Channel() Channel {
+	return ChannelTeams
+}
+
+func (s *TeamsService) BuildMessage(params MessageParams) any {
+	return s.buildCardMessage(params)
+}
+
+func (s *TeamsService) SendMessage(target string, params MessageParams) (*SendResult, error) {
+	return s.SendRawMessage(target, s.buildCardMessage(params))
+}
+
+// SendRawMessage posts to the webhook named by target.
+func (s *TeamsService) SendRawMessage(target string, message any) (*SendResult, error) {
+	slog.Info("Sending Teams message", "target", target)
+
+	webhookURL, ok := s.webhooks[target]
+	if !ok {
+		return nil, PermanentError("teams: unknown webhook %q", target)
+	}
+
+	body, err := json.Marshal(message)
+	if err != nil {
+		return nil, fmt.Errorf("marshal message: %w", err)
+	}
+
+	resp, err := s.client.Post(webhookURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return nil, TransientError("send message: %w", redactURLError(err, webhookURL))
+	}
+	defer resp.Body.Close()
+
+	text, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, statusError(resp, "teams error: %d - %s", resp.StatusCode, strings.TrimSpace(string(text)))
+	}
+	// Legacy incoming webhooks answer 200 and describe failures in the body,
+	// e.g. "Webhook message delivery failed with error: ... HTTP error 429".
+	if msg := string(text); strings.Contains(msg, "delivery failed") {
+		if strings.Contains(msg, "429") {
+			return nil, RateLimitedError(0, "teams error: %s", msg)
+		}
+		return nil, TransientError("teams error: %s", msg)
+	}
+	return &SendResult{Success: true}, nil
+}
+
+// TeamsCardMessage wraps Adaptive Card body elements and actions in the
+// message format Workflows and incoming webhooks accept.
+func TeamsCardMessage(body, actions []any) map[string]any {
+	card := map[string]any{
+		"$schema": "http://adaptivecards.io/schemas/adaptive-card.json",
+		"type":    "AdaptiveCard",
+		"version": "1.4",
+		"body":    body,
+		"msteams": map[string]any{"width": "Full"},
+	}
+	if len(actions) > 0 {
+		card["actions"] = actions
+	}
+	return map[string]any{
+		"type": "message",
+		"attachments": []any{map[string]any{
+			"contentType": "application/vnd.microsoft.card.adaptive",
+			"content":     card,
+		}},
+	}
+}
+
+// TeamsTitle returns a large bold TextBlock colored by color.
+func TeamsTitle(text string, color Color) map[string]any {
+	title := map[string]any{
+		"type":   "TextBlock",
+		"text":   text,
+		"size":   "Large",
+		"weight": "Bolder",
+		"wrap":   true,
+	}
+	if c, ok := teamsTextColors[color]; ok {
+		title["color"] = c
+	}
+	return title
+}
+
+// TeamsNote renders text as a subtle footer.
+func TeamsNote(text string) map[string]any {
+	return map[string]any{
+		"type":      "TextBlock",
+		"text":      text,
+		"wrap":      true,
+		"isSubtle":  true,
+		"size":      "Small",
+		"separator": true,
+	}
+}
+
+// buildCardMessage renders params as an Adaptive Card: the title as a bold
+// TextBlock colored by params.Color, each content paragraph as a TextBlock,
+// the note as a subtle footer and the URL as an Action.OpenUrl.
+func (s *TeamsService) buildCardMessage(params MessageParams) map[string]any {
+	var body []any
+
+	if params.Title != "" {
+		body = append(body, TeamsTitle(params.Title, params.Color))
+	}
+
+	body = append(body, teamsTextBlocks(params.Content)...)
+
+	if params.Note != "" {
+		body = append(body, TeamsNote(params.Note))
+	}
+
+	var actions []any
+	if params.URL != "" {
+		actions = append(actions, map[string]any{"type": "Action.OpenUrl", "title": "View Details", "url": params.URL})
+	}
+	return TeamsCardMessage(body, actions)
+}
+
+// teamsTextBlocks splits Markdown into one TextBlock per paragraph. TextBlock
+// Markdown has no headings, so "#" lines become bold blocks.
+func teamsTextBlocks(content string) []any {
+	var blocks []any
+	for _, paragraph := range strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n\n") {
+		paragraph = strings.TrimSpace(paragraph)
+		if paragraph == "" {
+			continue
+		}
+		block := map[string]any{"type": "TextBlock", "text": paragraph, "wrap": true}
+		if m := markdownHeading.FindStringSubmatch(paragraph); m != nil && !strings.Contains(paragraph, "\n") {
+			block["text"] = m[2]
+			block["weight"] = "Bolder"
+			if len(m[1]) <= 2 {
+				block["size"] = "Medium"
+			}
+		}
+		blocks = append(blocks, block)
+	}
+	return blocks
+}
@@ -0,0 +1,79 @@
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"notify/internal/config"
+)
+
+func TestTeamsSendRawMessage(t *testing.T) {
+	var gotBody map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&gotBody)
+		switch r.URL.Path {
+		case "/legacy-throttled":
+			_, _ = w.Write([]byte("Webhook message delivery failed with error: Microsoft Teams endpoint returned HTTP error 429"))
+		case "/gone":
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			w.WriteHeader(http.StatusAccepted)
+		}
+	}))
+	defer srv.Close()
+
+	svc := NewTeamsService(config.TeamsConfig{Webhooks: map[string]string{
+		"ops":       srv.URL + "/workflow",
+		"throttled": srv.URL + "/legacy-throttled",
+		"gone":      srv.URL + "/gone",
+	}})
+
+	if _, err := svc.SendMessage("ops", MessageParams{Content: "hi"}); err != nil {
+		t.Fatalf("SendMessage() error = %v", err)
+	}
+	if gotBody["type"] != "message" {
+		t.Fatalf("body = %v", gotBody)
+	}
+
+	_, err := svc.SendMessage("throttled", MessageParams{Content: "hi"})
+	if kind, _ := ClassifyError(err); kind != ErrorRateLimited {
+		t.Fatalf("legacy 429 = %v; error = %v", kind, err)
+	}
+	_, err = svc.SendMessage("gone", MessageParams{Content: "hi"})
+	if kind, _ := ClassifyError(err); kind != ErrorPermanent {
+		t.Fatalf("404 = %v; error = %v", kind, err)
+	}
+	_, err = svc.SendMessage("missing", MessageParams{Content: "hi"})
+	if kind, _ := ClassifyError(err); kind != ErrorPermanent {
+		t.Fatalf("unknown webhook = %v; error = %v", kind, err)
+	}
+}
+
+func TestTeamsBuildCardMessage(t *testing.T) {
+	svc := NewTeamsService(config.TeamsConfig{})
+	message := svc.buildCardMessage(MessageParams{
+		Title:   "Deploy",
+		Color:   ColorRed,
+		Content: "## Summary\n\n**3** services failed",
+		Note:    "by ci",
+		URL:     "https://example.com",
+	})
+
+	card := message["attachments"].([]any)[0].(map[string]any)["content"].(map[string]any)
+	wantBody := []any{
+		map[string]any{"type": "TextBlock", "text": "Deploy", "size": "Large", "weight": "Bolder", "wrap": true, "color": "attention"},
+		map[string]any{"type": "TextBlock", "text": "Summary", "wrap": true, "weight": "Bolder", "size": "Medium"},
+		map[string]any{"type": "TextBlock", "text": "**3** services failed", "wrap": true},
+		map[string]any{"type": "TextBlock", "text": "by ci", "wrap": true, "isSubtle": true, "size": "Small", "separator": true},
+	}
+	if !reflect.DeepEqual(card["body"], wantBody) {
+		t.Fatalf("body = %#v", card["body"])
+	}
+	wantActions := []any{map[string]any{"type": "Action.OpenUrl", "title": "View Details", "url": "https://example.com"}}
+	if !reflect.DeepEqual(card["actions"], wantActions) {
+		t.Fatalf("actions = %#v", card["actions"])
+	}
+}
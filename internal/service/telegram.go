@@ -85,28 +85,75 @@ func (s *TelegramService) SendRawMessage(target string, message any) (*SendResul
 
 	resp, err := s.client.Post(url, "application/json", bytes.NewReader(body))
 	if err != nil {
-		return nil, fmt.Errorf("send message: %w", err)
+		return nil, TransientError("send message: %w", redactURLError(err, s.botToken))
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected status: %d", resp.StatusCode)
-	}
-
+	// Telegram describes failures in the body for 4xx responses as well.
 	var result struct {
 		OK          bool   `json:"ok"`
 		ErrorCode   int    `json:"error_code"`
 		Description string `json:"description"`
+		Parameters  struct {
+			RetryAfter int `json:"retry_after"`
+		} `json:"parameters"`
+		Result struct {
+			MessageID int `json:"message_id"`
+		} `json:"result"`
 	}
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("decode response: %w", err)
+		if resp.StatusCode != http.StatusOK {
+			return nil, sendStatusError(resp.StatusCode)
+		}
+		return nil, TransientError("decode response: %w", err)
 	}
 
 	if !result.OK {
-		return nil, fmt.Errorf("telegram error: %d - %s", result.ErrorCode, result.Description)
+		return nil, telegramError(resp.StatusCode, result.ErrorCode, result.Description, result.Parameters.RetryAfter)
 	}
 
-	return &SendResult{Success: true}, nil
+	return &SendResult{Success: true, MessageID: strconv.Itoa(result.Result.MessageID)}, nil
+}
+
+// CheckHealth calls getMe to confirm the bot token is valid.
+func (s *TelegramService) CheckHealth() error {
+	resp, err := s.client.Get(s.baseURL + "/getMe")
+	if err != nil {
+		return fmt.Errorf("getMe: %w", redactURLError(err, s.botToken))
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		OK          bool   `json:"ok"`
+		Description string `json:"description"`
+		Result      struct {
+			Username string `json:"username"`
+		} `json:"result"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("getMe: unexpected status: %d", resp.StatusCode)
+	}
+	if !result.OK {
+		return fmt.Errorf("getMe: %d - %s", resp.StatusCode, result.Description)
+	}
+	slog.Debug("Telegram bot authenticated", "username", result.Result.Username)
+	return nil
+}
+
+// telegramError classifies a failed Bot API call. 429 carries retry_after;
+// other 4xx such as "chat not found" or "bot was kicked" will not recover on retry.
+func telegramError(status, code int, description string, retryAfter int) error {
+	if code == 0 {
+		code = status
+	}
+	switch statusErrorKind(code) {
+	case ErrorRateLimited:
+		return RateLimitedError(time.Duration(retryAfter)*time.Second, "telegram error: %d - %s", code, description)
+	case ErrorPermanent:
+		return PermanentError("telegram error: %d - %s", code, description)
+	default:
+		return TransientError("telegram error: %d - %s", code, description)
+	}
 }
 
 func (s *TelegramService) buildMessage(params MessageParams) string {
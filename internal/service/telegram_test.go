@@ -0,0 +1,45 @@
+package service
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"notify/internal/config"
+)
+
+func TestTelegramCheckHealth(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/botgood/getMe" {
+			_, _ = w.Write([]byte(`{"ok":true,"result":{"id":1,"username":"notify_bot"}}`))
+			return
+		}
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"ok":false,"error_code":401,"description":"Unauthorized"}`))
+	}))
+	defer srv.Close()
+
+	svc := NewTelegramService(config.TelegramConfig{BotToken: "good"})
+	svc.baseURL = srv.URL + "/botgood"
+	if err := svc.CheckHealth(); err != nil {
+		t.Fatalf("CheckHealth() error = %v", err)
+	}
+
+	svc.botToken = "bad"
+	svc.baseURL = srv.URL + "/botbad"
+	if err := svc.CheckHealth(); err == nil || !strings.Contains(err.Error(), "Unauthorized") {
+		t.Fatalf("CheckHealth() error = %v, want Unauthorized", err)
+	}
+	svc.botToken = "good"
+	svc.baseURL = srv.URL + "/botgood"
+
+	// Transport errors must not echo the URL, which contains the token.
+	srv.Close()
+	if err := svc.CheckHealth(); err == nil || strings.Contains(err.Error(), "good") {
+		t.Fatalf("CheckHealth() error = %v, want error without token", err)
+	}
+	if _, err := svc.SendRawMessage("-100", map[string]any{"text": "hi"}); err == nil || strings.Contains(err.Error(), "good") {
+		t.Fatalf("SendRawMessage() error = %v, want error without token", err)
+	}
+}
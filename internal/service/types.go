@@ -5,6 +5,18 @@ type Channel string
 const (
 	ChannelFeishu   Channel = "feishu"
 	ChannelTelegram Channel = "telegram"
+	ChannelSlack    Channel = "slack"
+	ChannelDiscord  Channel = "discord"
+	ChannelDingTalk Channel = "dingtalk"
+	ChannelWeCom    Channel = "wecom"
+	ChannelEmail    Channel = "email"
+	ChannelTeams    Channel = "teams"
+	ChannelNtfy     Channel = "ntfy"
+	ChannelGotify   Channel = "gotify"
+	ChannelBark     Channel = "bark"
+	ChannelPushover Channel = "pushover"
+	ChannelMatrix   Channel = "matrix"
+	ChannelWebhook  Channel = "webhook"
 )
 
 type Color string
@@ -18,16 +30,35 @@ const (
 	ColorPurple Color = "Purple"
 )
 
+// colorPalette gives the colors as hex values for channels that take any color.
+var colorPalette = map[Color]string{
+	ColorBlue:   "#2F80ED",
+	ColorGreen:  "#2EB67D",
+	ColorOrange: "#F2994A",
+	ColorGrey:   "#9E9E9E",
+	ColorRed:    "#E01E5A",
+	ColorPurple: "#7B61FF",
+}
+
+// colorHex returns c as "#RRGGBB", or "" for an unknown color.
+func colorHex(c Color) string {
+	return colorPalette[c]
+}
+
 type MessageParams struct {
 	Title   string `json:"title,omitempty"`
 	Color   Color  `json:"color,omitempty"`
 	Content string `json:"content,omitempty"`
 	URL     string `json:"url,omitempty"`
 	Note    string `json:"note,omitempty"`
+	// Mentions lists mobile numbers to @, or "all" for everyone, on channels
+	// that support it.
+	Mentions []string `json:"mentions,omitempty"`
 }
 
 type SendResult struct {
-	Success bool `json:"success"`
+	Success   bool   `json:"success"`
+	MessageID string `json:"messageId,omitempty"`
 }
 
 type ChatItem struct {
@@ -0,0 +1,163 @@
+package service
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"notify/internal/config"
+)
+
+// defaultWebhookTemplate sends the message itself as JSON.
+const defaultWebhookTemplate = `{{json .Message}}`
+
+// webhookTemplateFuncs are available in endpoint templates.
+var webhookTemplateFuncs = template.FuncMap{
+	// json encodes a value, so strings can be placed in JSON bodies safely.
+	"json": func(v any) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+}
+
+// WebhookTemplateData is the data endpoint templates are executed with.
+// Message is the message as JSON would decode it; the MessageParams fields
+// are filled when the message has them, as messages from /api/messages do.
+type WebhookTemplateData struct {
+	Target   string
+	Title    string
+	Color    Color
+	Content  string
+	URL      string
+	Note     string
+	Mentions []string
+	Message  any
+}
+
+type webhookEndpoint struct {
+	config.WebhookEndpoint
+	template *template.Template
+}
+
+type WebhookService struct {
+	endpoints map[string]webhookEndpoint
+	client    *http.Client
+}
+
+// NewWebhookService parses the endpoint templates.
+func NewWebhookService(cfg config.OutboundConfig) (*WebhookService, error) {
+	s := &WebhookService{
+		endpoints: make(map[string]webhookEndpoint, len(cfg.Endpoints)),
+		client:    &http.Client{Timeout: 30 * time.Second},
+	}
+	for name, endpoint := range cfg.Endpoints {
+		text := endpoint.Template
+		if text == "" {
+			text = defaultWebhookTemplate
+		}
+		tmpl, err := template.New(name).Funcs(webhookTemplateFuncs).Option("missingkey=zero").Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("webhook: endpoint %q: %w", name, err)
+		}
+		s.endpoints[name] = webhookEndpoint{WebhookEndpoint: endpoint, template: tmpl}
+	}
+	return s, nil
+}
+
+func (s *WebhookService) Channel() Channel {
+	return ChannelWebhook
+}
+
+// BuildMessage keeps params as they are; endpoint templates shape the body.
+func (s *WebhookService) BuildMessage(params MessageParams) any {
+	return params
+}
+
+func (s *WebhookService) SendMessage(target string, params MessageParams) (*SendResult, error) {
+	return s.SendRawMessage(target, params)
+}
+
+// SendRawMessage renders the endpoint's template with message and sends the
+// result to the endpoint named by target.
+func (s *WebhookService) SendRawMessage(target string, message any) (*SendResult, error) {
+	slog.Info("Sending webhook", "target", target)
+
+	endpoint, ok := s.endpoints[target]
+	if !ok {
+		return nil, PermanentError("webhook: unknown endpoint %q", target)
+	}
+
+	data, err := webhookTemplateData(target, message)
+	if err != nil {
+		return nil, PermanentError("webhook: %w", err)
+	}
+	var body bytes.Buffer
+	if err := endpoint.template.Execute(&body, data); err != nil {
+		return nil, PermanentError("webhook: render %q: %w", target, err)
+	}
+
+	req, err := http.NewRequest(endpoint.Method, endpoint.URL, bytes.NewReader(body.Bytes()))
+	if err != nil {
+		return nil, PermanentError("webhook: create request: %w", redactURLError(err, endpoint.URL))
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range endpoint.Headers {
+		req.Header.Set(name, value)
+	}
+	if endpoint.Secret != "" {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		req.Header.Set(endpoint.TimestampHeader, timestamp)
+		req.Header.Set(endpoint.SignatureHeader, "sha256="+webhookSignature(endpoint.Secret, timestamp, body.Bytes()))
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, TransientError("send webhook: %w", redactURLError(err, endpoint.URL))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		text, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, statusError(resp, "webhook error: %d - %s", resp.StatusCode, strings.TrimSpace(string(text)))
+	}
+	return &SendResult{Success: true}, nil
+}
+
+// webhookSignature is the hex HMAC-SHA256 of "timestamp:body", the scheme
+// inbound webhooks are verified with.
+func webhookSignature(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + ":"))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// webhookTemplateData normalizes message through JSON so typed messages and
+// messages restored from the task store render the same way.
+func webhookTemplateData(target string, message any) (WebhookTemplateData, error) {
+	data := WebhookTemplateData{Target: target}
+	raw, err := json.Marshal(message)
+	if err != nil {
+		return data, fmt.Errorf("marshal message: %w", err)
+	}
+	if err := json.Unmarshal(raw, &data.Message); err != nil {
+		return data, fmt.Errorf("decode message: %w", err)
+	}
+
+	var params MessageParams
+	if json.Unmarshal(raw, &params) == nil {
+		data.Title, data.Color, data.Content = params.Title, params.Color, params.Content
+		data.URL, data.Note, data.Mentions = params.URL, params.Note, params.Mentions
+	}
+	return data, nil
+}
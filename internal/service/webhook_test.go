@@ -0,0 +1,87 @@
+package service
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"notify/internal/config"
+)
+
+func TestWebhookSendMessage(t *testing.T) {
+	var gotMethod, gotBody string
+	var gotHeader http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		gotMethod, gotBody, gotHeader = r.Method, string(body), r.Header
+		switch r.URL.Path {
+		case "/down":
+			w.WriteHeader(http.StatusBadGateway)
+		case "/invalid":
+			w.WriteHeader(http.StatusUnprocessableEntity)
+		}
+	}))
+	defer srv.Close()
+
+	svc, err := NewWebhookService(config.OutboundConfig{Endpoints: map[string]config.WebhookEndpoint{
+		"tickets": {
+			URL:             srv.URL + "/tickets",
+			Method:          http.MethodPut,
+			Headers:         map[string]string{"Authorization": "Bearer t"},
+			Template:        `{"summary":{{json .Title}},"body":{{json .Content}},"queue":{{json .Target}}}`,
+			Secret:          "s3cret",
+			SignatureHeader: "X-Notify-Signature",
+			TimestampHeader: "X-Notify-Timestamp",
+		},
+		"status":  {URL: srv.URL + "/status", Method: http.MethodPost},
+		"down":    {URL: srv.URL + "/down", Method: http.MethodPost},
+		"invalid": {URL: srv.URL + "/invalid", Method: http.MethodPost},
+	}})
+	if err != nil {
+		t.Fatalf("NewWebhookService() error = %v", err)
+	}
+
+	params := MessageParams{Title: "Disk \"full\"", Content: "host-1"}
+	if _, err := svc.SendMessage("tickets", params); err != nil {
+		t.Fatalf("SendMessage() error = %v", err)
+	}
+	if gotMethod != http.MethodPut || gotBody != `{"summary":"Disk \"full\"","body":"host-1","queue":"tickets"}` {
+		t.Fatalf("request = %s %s", gotMethod, gotBody)
+	}
+	timestamp := gotHeader.Get("X-Notify-Timestamp")
+	wantSignature := "sha256=" + webhookSignature("s3cret", timestamp, []byte(gotBody))
+	if gotHeader.Get("Authorization") != "Bearer t" || gotHeader.Get("X-Notify-Signature") != wantSignature {
+		t.Fatalf("headers = %v", gotHeader)
+	}
+
+	if _, err := svc.SendRawMessage("status", map[string]any{"component": "api", "status": "degraded"}); err != nil {
+		t.Fatalf("SendRawMessage() error = %v", err)
+	}
+	if gotBody != `{"component":"api","status":"degraded"}` || gotHeader.Get("X-Notify-Signature") != "" {
+		t.Fatalf("raw request = %s %v", gotBody, gotHeader)
+	}
+
+	_, err = svc.SendMessage("down", params)
+	if kind, _ := ClassifyError(err); kind != ErrorTransient {
+		t.Fatalf("502 = %v; error = %v", kind, err)
+	}
+	_, err = svc.SendMessage("invalid", params)
+	if kind, _ := ClassifyError(err); kind != ErrorPermanent {
+		t.Fatalf("422 = %v; error = %v", kind, err)
+	}
+	_, err = svc.SendMessage("missing", params)
+	if kind, _ := ClassifyError(err); kind != ErrorPermanent {
+		t.Fatalf("unknown endpoint = %v; error = %v", kind, err)
+	}
+}
+
+func TestWebhookInvalidTemplate(t *testing.T) {
+	_, err := NewWebhookService(config.OutboundConfig{Endpoints: map[string]config.WebhookEndpoint{
+		"broken": {URL: "https://example.com", Method: http.MethodPost, Template: "{{.Title"},
+	}})
+	if err == nil || !strings.Contains(err.Error(), `"broken"`) {
+		t.Fatalf("NewWebhookService() error = %v", err)
+	}
+}
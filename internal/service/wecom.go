@@ -0,0 +1,422 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"notify/internal/config"
+	"notify/internal/metrics"
+)
+
+// wecomMarkdownColors maps message colors to the three font colors WeCom
+// markdown supports.
+var wecomMarkdownColors = map[Color]string{
+	ColorGreen:  "info",
+	ColorGrey:   "comment",
+	ColorOrange: "warning",
+	ColorRed:    "warning",
+}
+
+// wecomCardColors maps message colors to template card source colors:
+// 0 grey, 1 black, 2 red, 3 green.
+var wecomCardColors = map[Color]int{
+	ColorBlue:   1,
+	ColorGreen:  3,
+	ColorOrange: 2,
+	ColorGrey:   0,
+	ColorRed:    2,
+	ColorPurple: 1,
+}
+
+// WeCom error codes that need special handling.
+const (
+	wecomCodeInvalidUser    = 40003
+	wecomCodeInvalidToken   = 40014
+	wecomCodeInvalidAgentID = 40056
+	wecomCodeInvalidParam   = 40058
+	wecomCodeTokenExpired   = 42001
+	wecomCodeEmptyContent   = 44004
+	wecomCodeRateLimited    = 45009
+	wecomCodeIPNotAllowed   = 60020
+	wecomCodeNoRecipients   = 81013
+	wecomCodeInvalidRobot   = 93000
+)
+
+type WeComService struct {
+	corpID     string
+	corpSecret string
+	agentID    int
+	robots     map[string]string
+	apiURL     string
+	client     *http.Client
+
+	token    string
+	tokenExp time.Time
+	tokenMu  sync.RWMutex
+}
+
+func NewWeComService(cfg config.WeComConfig) *WeComService {
+	return &WeComService{
+		corpID:     cfg.CorpID,
+		corpSecret: cfg.CorpSecret,
+		agentID:    cfg.AgentID,
+		robots:     cfg.Robots,
+		apiURL:     strings.TrimSuffix(cfg.APIURL, "/"),
+		client:     &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+func (s *WeComService) Channel() Channel {
+	return ChannelWeCom
+}
+
+func (s *WeComService) BuildMessage(params MessageParams) any {
+	return s.buildMessage(params)
+}
+
+func (s *WeComService) SendMessage(target string, params MessageParams) (*SendResult, error) {
+	return s.SendRawMessage(target, s.buildMessage(params))
+}
+
+// SendRawMessage posts to the group robot named by target, or otherwise sends
+// an application message. App targets are user IDs separated by "|", "@all",
+// or "party:" and "tag:" followed by department or tag IDs.
+func (s *WeComService) SendRawMessage(target string, message any) (*SendResult, error) {
+	slog.Info("Sending WeCom message", "target", target)
+
+	if key, ok := s.robots[target]; ok {
+		return s.sendRobot(robotKey(key), message)
+	}
+	if s.corpID == "" {
+		return nil, PermanentError("wecom: no robot named %q and no application configured", target)
+	}
+
+	payload := map[string]any{}
+	if m, ok := message.(map[string]any); ok {
+		for k, v := range m {
+			payload[k] = v
+		}
+	}
+	payload["agentid"] = s.agentID
+	switch {
+	case strings.HasPrefix(target, "party:"):
+		payload["toparty"] = strings.TrimPrefix(target, "party:")
+	case strings.HasPrefix(target, "tag:"):
+		payload["totag"] = strings.TrimPrefix(target, "tag:")
+	default:
+		payload["touser"] = target
+	}
+
+	token, err := s.getAccessToken()
+	if err != nil {
+		return nil, TransientError("get access token: %w", err)
+	}
+
+	var result struct {
+		MsgID       string `json:"msgid"`
+		InvalidUser string `json:"invaliduser"`
+	}
+	if err := s.post("/cgi-bin/message/send?access_token="+url.QueryEscape(token), payload, token, &result); err != nil {
+		return nil, err
+	}
+	if result.InvalidUser != "" {
+		slog.Warn("WeCom skipped invalid users", "target", target, "invalid", result.InvalidUser)
+	}
+	return &SendResult{Success: true, MessageID: result.MsgID}, nil
+}
+
+// robotKey accepts either a webhook key or the full webhook URL.
+func robotKey(value string) string {
+	if u, err := url.Parse(value); err == nil && u.Query().Has("key") {
+		return u.Query().Get("key")
+	}
+	return value
+}
+
+func (s *WeComService) sendRobot(key string, message any) (*SendResult, error) {
+	if err := s.post("/cgi-bin/webhook/send?key="+url.QueryEscape(key), message, key, nil); err != nil {
+		return nil, err
+	}
+	return &SendResult{Success: true}, nil
+}
+
+// post sends a JSON request and decodes the response into result. secret is
+// removed from transport errors.
+func (s *WeComService) post(path string, payload any, secret string, result any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("marshal message: %w", err)
+	}
+
+	resp, err := s.client.Post(s.apiURL+path, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return TransientError("send message: %w", redactURLError(err, secret))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return sendStatusError(resp.StatusCode)
+	}
+
+	var data json.RawMessage
+	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		return TransientError("decode response: %w", err)
+	}
+	var status struct {
+		ErrCode int    `json:"errcode"`
+		ErrMsg  string `json:"errmsg"`
+	}
+	if err := json.Unmarshal(data, &status); err != nil {
+		return TransientError("decode response: %w", err)
+	}
+	if status.ErrCode != 0 {
+		return s.sendError(status.ErrCode, status.ErrMsg)
+	}
+	if result != nil {
+		if err := json.Unmarshal(data, result); err != nil {
+			return TransientError("decode response: %w", err)
+		}
+	}
+	return nil
+}
+
+func (s *WeComService) sendError(code int, msg string) error {
+	switch code {
+	case wecomCodeRateLimited:
+		return RateLimitedError(0, "wecom error: %d - %s", code, msg)
+	case wecomCodeInvalidToken, wecomCodeTokenExpired:
+		// Drop the cached token so the retry fetches a new one.
+		s.tokenMu.Lock()
+		s.token = ""
+		s.tokenMu.Unlock()
+		return TransientError("wecom error: %d - %s", code, msg)
+	case wecomCodeInvalidUser, wecomCodeInvalidAgentID, wecomCodeInvalidParam, wecomCodeEmptyContent,
+		wecomCodeIPNotAllowed, wecomCodeNoRecipients, wecomCodeInvalidRobot:
+		return PermanentError("wecom error: %d - %s", code, msg)
+	default:
+		return TransientError("wecom error: %d - %s", code, msg)
+	}
+}
+
+var (
+	wecomTokenRefreshes = metrics.NewCounterVec("notify_wecom_token_refreshes_total",
+		"WeCom access token refresh requests.")
+	wecomTokenRefreshFailures = metrics.NewCounterVec("notify_wecom_token_refresh_failures_total",
+		"WeCom access token refresh requests that failed.")
+)
+
+func (s *WeComService) getAccessToken() (string, error) {
+	s.tokenMu.RLock()
+	if s.token != "" && time.Now().Before(s.tokenExp) {
+		token := s.token
+		s.tokenMu.RUnlock()
+		return token, nil
+	}
+	s.tokenMu.RUnlock()
+
+	s.tokenMu.Lock()
+	defer s.tokenMu.Unlock()
+
+	// double-check after acquiring write lock
+	if s.token != "" && time.Now().Before(s.tokenExp) {
+		return s.token, nil
+	}
+
+	token, err := s.refreshAccessToken()
+	wecomTokenRefreshes.Inc()
+	if err != nil {
+		wecomTokenRefreshFailures.Inc()
+		return "", err
+	}
+	return token, nil
+}
+
+// refreshAccessToken fetches a new token. Caller must hold s.tokenMu.
+func (s *WeComService) refreshAccessToken() (string, error) {
+	query := url.Values{"corpid": {s.corpID}, "corpsecret": {s.corpSecret}}
+	resp, err := s.client.Get(s.apiURL + "/cgi-bin/gettoken?" + query.Encode())
+	if err != nil {
+		return "", redactURLError(err, s.corpSecret)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status: %d", resp.StatusCode)
+	}
+
+	var result struct {
+		ErrCode     int    `json:"errcode"`
+		ErrMsg      string `json:"errmsg"`
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", err
+	}
+
+	if result.ErrCode != 0 {
+		return "", fmt.Errorf("get token failed: %d - %s", result.ErrCode, result.ErrMsg)
+	}
+
+	s.token = result.AccessToken
+	// ExpiresIn is in seconds, reduce by 60s buffer
+	s.tokenExp = time.Now().Add(time.Duration(result.ExpiresIn-60) * time.Second)
+
+	return result.AccessToken, nil
+}
+
+// get calls a read API with the access token and decodes the response.
+func (s *WeComService) get(path string, result any) error {
+	token, err := s.getAccessToken()
+	if err != nil {
+		return fmt.Errorf("get access token: %w", err)
+	}
+
+	resp, err := s.client.Get(s.apiURL + path + "?access_token=" + url.QueryEscape(token))
+	if err != nil {
+		return redactURLError(err, token)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status: %d", resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+	return nil
+}
+
+// ListChats lists the configured group robots and, for the application, the
+// departments and tags it can send to. WeCom has no API to enumerate the
+// group chats a robot is in.
+func (s *WeComService) ListChats() ([]ChatItem, error) {
+	var chats []ChatItem
+	for _, name := range slices.Sorted(maps.Keys(s.robots)) {
+		chats = append(chats, ChatItem{ChatID: name, Name: name, Description: "Group robot"})
+	}
+	if s.corpID == "" {
+		return chats, nil
+	}
+
+	var departments struct {
+		ErrCode    int    `json:"errcode"`
+		ErrMsg     string `json:"errmsg"`
+		Department []struct {
+			ID   int    `json:"id"`
+			Name string `json:"name"`
+		} `json:"department"`
+	}
+	if err := s.get("/cgi-bin/department/list", &departments); err != nil {
+		return nil, fmt.Errorf("list departments: %w", err)
+	}
+	if departments.ErrCode != 0 {
+		return nil, fmt.Errorf("wecom error: %d - %s", departments.ErrCode, departments.ErrMsg)
+	}
+	for _, d := range departments.Department {
+		chats = append(chats, ChatItem{ChatID: "party:" + strconv.Itoa(d.ID), Name: d.Name, Description: "Department"})
+	}
+
+	var tags struct {
+		ErrCode int    `json:"errcode"`
+		ErrMsg  string `json:"errmsg"`
+		TagList []struct {
+			TagID   int    `json:"tagid"`
+			TagName string `json:"tagname"`
+		} `json:"taglist"`
+	}
+	if err := s.get("/cgi-bin/tag/list", &tags); err != nil {
+		return nil, fmt.Errorf("list tags: %w", err)
+	}
+	if tags.ErrCode != 0 {
+		return nil, fmt.Errorf("wecom error: %d - %s", tags.ErrCode, tags.ErrMsg)
+	}
+	for _, t := range tags.TagList {
+		chats = append(chats, ChatItem{ChatID: "tag:" + strconv.Itoa(t.TagID), Name: t.TagName, Description: "Tag"})
+	}
+
+	return chats, nil
+}
+
+// CheckHealth confirms the application credentials can obtain an access
+// token. Group robots cannot be checked without posting a message.
+func (s *WeComService) CheckHealth() error {
+	if s.corpID == "" {
+		return nil
+	}
+	_, err := s.getAccessToken()
+	return err
+}
+
+// buildMessage renders params as a text_notice template card when there is a
+// URL, which such cards require, and as markdown otherwise.
+func (s *WeComService) buildMessage(params MessageParams) map[string]any {
+	if params.URL != "" {
+		return map[string]any{
+			"msgtype":       "template_card",
+			"template_card": s.buildTemplateCard(params),
+		}
+	}
+
+	var parts []string
+	if params.Title != "" {
+		heading := params.Title
+		if color, ok := wecomMarkdownColors[params.Color]; ok {
+			heading = fmt.Sprintf(`<font color="%s">%s</font>`, color, heading)
+		}
+		parts = append(parts, "### "+heading)
+	}
+	if params.Content != "" {
+		parts = append(parts, params.Content)
+	}
+	if params.Note != "" {
+		parts = append(parts, "> "+strings.ReplaceAll(params.Note, "\n", "\n> "))
+	}
+
+	return map[string]any{
+		"msgtype":  "markdown",
+		"markdown": map[string]any{"content": strings.Join(parts, "\n\n")},
+	}
+}
+
+// buildTemplateCard puts the title in the card's source line, colored by
+// params.Color, the content in the subtitle and the note in a quote.
+func (s *WeComService) buildTemplateCard(params MessageParams) map[string]any {
+	card := map[string]any{
+		"card_type":   "text_notice",
+		"card_action": map[string]any{"type": 1, "url": params.URL},
+	}
+
+	if params.Title != "" {
+		source := map[string]any{"desc": params.Title}
+		if color, ok := wecomCardColors[params.Color]; ok {
+			source["desc_color"] = color
+		}
+		card["source"] = source
+	}
+
+	// A card needs a main title or a subtitle.
+	switch {
+	case params.Content != "":
+		card["sub_title_text"] = params.Content
+	case params.Title != "":
+		card["main_title"] = map[string]any{"title": params.Title}
+	default:
+		card["main_title"] = map[string]any{"title": params.URL}
+	}
+
+	if params.Note != "" {
+		card["quote_area"] = map[string]any{"quote_text": params.Note}
+	}
+	return card
+}
@@ -0,0 +1,134 @@
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"notify/internal/config"
+)
+
+func TestWeComSendRawMessage(t *testing.T) {
+	var tokenRequests int
+	var gotPath, gotQuery string
+	var gotBody map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/cgi-bin/gettoken":
+			tokenRequests++
+			_, _ = w.Write([]byte(`{"errcode":0,"access_token":"app-token","expires_in":7200}`))
+			return
+		case "/cgi-bin/department/list":
+			_, _ = w.Write([]byte(`{"errcode":0,"department":[{"id":2,"name":"Ops"}]}`))
+			return
+		case "/cgi-bin/tag/list":
+			_, _ = w.Write([]byte(`{"errcode":0,"taglist":[{"tagid":1,"tagname":"oncall"}]}`))
+			return
+		}
+
+		gotPath, gotQuery = r.URL.Path, r.URL.RawQuery
+		gotBody = nil
+		_ = json.NewDecoder(r.Body).Decode(&gotBody)
+		switch {
+		case r.URL.Query().Get("key") == "busy":
+			_, _ = w.Write([]byte(`{"errcode":45009,"errmsg":"api freq out of limit"}`))
+		case gotBody["touser"] == "nobody":
+			_, _ = w.Write([]byte(`{"errcode":81013,"errmsg":"user & party & tag all invalid"}`))
+		default:
+			_, _ = w.Write([]byte(`{"errcode":0,"errmsg":"ok","msgid":"msg-1"}`))
+		}
+	}))
+	defer srv.Close()
+
+	svc := NewWeComService(config.WeComConfig{
+		CorpID:     "corp",
+		CorpSecret: "secret",
+		AgentID:    1000002,
+		Robots: map[string]string{
+			"ops":  srv.URL + "/cgi-bin/webhook/send?key=ops-key",
+			"busy": "busy",
+		},
+		APIURL: srv.URL,
+	})
+	message := map[string]any{"msgtype": "markdown", "markdown": map[string]any{"content": "hi"}}
+
+	if _, err := svc.SendRawMessage("ops", message); err != nil {
+		t.Fatalf("SendRawMessage(robot) error = %v", err)
+	}
+	if gotPath != "/cgi-bin/webhook/send" || gotQuery != "key=ops-key" || gotBody["msgtype"] != "markdown" {
+		t.Fatalf("robot request = %s?%s %v", gotPath, gotQuery, gotBody)
+	}
+
+	tests := []struct {
+		target, field, value string
+	}{
+		{target: "alice|bob", field: "touser", value: "alice|bob"},
+		{target: "party:2", field: "toparty", value: "2"},
+		{target: "tag:1", field: "totag", value: "1"},
+	}
+	for _, tt := range tests {
+		result, err := svc.SendRawMessage(tt.target, message)
+		if err != nil {
+			t.Fatalf("SendRawMessage(%q) error = %v", tt.target, err)
+		}
+		if result.MessageID != "msg-1" || gotPath != "/cgi-bin/message/send" || gotQuery != "access_token=app-token" ||
+			gotBody[tt.field] != tt.value || gotBody["agentid"] != float64(1000002) {
+			t.Fatalf("SendRawMessage(%q) sent %s?%s %v", tt.target, gotPath, gotQuery, gotBody)
+		}
+	}
+	if tokenRequests != 1 {
+		t.Fatalf("token requests = %d, want cached token", tokenRequests)
+	}
+
+	_, err := svc.SendRawMessage("busy", message)
+	if kind, _ := ClassifyError(err); kind != ErrorRateLimited {
+		t.Fatalf("45009 = %v; error = %v", kind, err)
+	}
+	_, err = svc.SendRawMessage("nobody", message)
+	if kind, _ := ClassifyError(err); kind != ErrorPermanent {
+		t.Fatalf("81013 = %v; error = %v", kind, err)
+	}
+
+	chats, err := svc.ListChats()
+	if err != nil {
+		t.Fatalf("ListChats() error = %v", err)
+	}
+	want := []ChatItem{
+		{ChatID: "busy", Name: "busy", Description: "Group robot"},
+		{ChatID: "ops", Name: "ops", Description: "Group robot"},
+		{ChatID: "party:2", Name: "Ops", Description: "Department"},
+		{ChatID: "tag:1", Name: "oncall", Description: "Tag"},
+	}
+	if !reflect.DeepEqual(chats, want) {
+		t.Fatalf("ListChats() = %v", chats)
+	}
+}
+
+func TestWeComBuildMessage(t *testing.T) {
+	svc := NewWeComService(config.WeComConfig{})
+
+	markdown := svc.buildMessage(MessageParams{Title: "Disk", Color: ColorRed, Content: "full", Note: "host-1"})
+	wantMarkdown := map[string]any{
+		"msgtype":  "markdown",
+		"markdown": map[string]any{"content": "### <font color=\"warning\">Disk</font>\n\nfull\n\n> host-1"},
+	}
+	if !reflect.DeepEqual(markdown, wantMarkdown) {
+		t.Fatalf("markdown = %#v", markdown)
+	}
+
+	card := svc.buildMessage(MessageParams{Title: "Deploy", Color: ColorGreen, Content: "done", URL: "https://example.com"})
+	wantCard := map[string]any{
+		"msgtype": "template_card",
+		"template_card": map[string]any{
+			"card_type":      "text_notice",
+			"card_action":    map[string]any{"type": 1, "url": "https://example.com"},
+			"source":         map[string]any{"desc": "Deploy", "desc_color": 3},
+			"sub_title_text": "done",
+		},
+	}
+	if !reflect.DeepEqual(card, wantCard) {
+		t.Fatalf("template card = %#v", card)
+	}
+}
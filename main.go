@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"slices"
 	"strings"
 	"syscall"
 	"time"
@@ -14,6 +15,8 @@ import (
 
 	"notify/internal/config"
 	"notify/internal/handler"
+	"notify/internal/logging"
+	"notify/internal/metrics"
 	"notify/internal/queue"
 	"notify/internal/service"
 )
@@ -21,11 +24,11 @@ import (
 func main() {
 	// Setup structured logging with color support
 	level := logLevelFromEnv()
-	logger := slog.New(tint.NewHandler(os.Stdout, &tint.Options{
+	logHandler := tint.NewHandler(os.Stdout, &tint.Options{
 		Level:      level,
 		TimeFormat: time.TimeOnly,
-	}))
-	slog.SetDefault(logger)
+	})
+	slog.SetDefault(slog.New(logHandler))
 
 	// Load and validate configuration
 	cfg, err := config.Load()
@@ -34,20 +37,47 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Scrub configured credentials and secret-looking values from all logs
+	redactor, err := logging.NewRedactor(slices.Concat(logging.DefaultPatterns, cfg.Log.RedactPatterns), cfg.Secrets()...)
+	if err != nil {
+		slog.Error("Configuration error", "error", err)
+		os.Exit(1)
+	}
+	slog.SetDefault(slog.New(logging.NewHandler(logHandler, redactor)))
+	handler.InitLogging(cfg.Log)
+
 	// Initialize services
-	service.Init(cfg)
+	if err := service.Init(cfg); err != nil {
+		slog.Error("Configuration error", "error", err)
+		os.Exit(1)
+	}
 
-	// Initialize queue
-	queue.Init(cfg.Queue)
+	// Initialize queue and replay persisted tasks
+	if err := queue.Init(cfg.Queue); err != nil {
+		slog.Error("Queue initialization failed", "error", err)
+		os.Exit(1)
+	}
 
 	// Setup routes
 	mux := http.NewServeMux()
 
 	// API routes
-	mux.HandleFunc("POST /api/messages", handler.SendMessage)
-	mux.HandleFunc("POST /api/messages/raw", handler.SendRawMessage)
-	mux.HandleFunc("GET /api/chats", handler.ListChats)
-	mux.HandleFunc("POST /api/webhooks/grafana", handler.HandleGrafanaWebhook)
+	handler.InitAuth(cfg.Auth)
+	mux.HandleFunc("POST /api/messages", handler.RequireScope(config.ScopeSend, handler.SendMessage))
+	mux.HandleFunc("POST /api/messages/raw", handler.RequireScope(config.ScopeRaw, handler.SendRawMessage))
+	mux.HandleFunc("GET /api/chats", handler.RequireScope(config.ScopeChats, handler.ListChats))
+	mux.HandleFunc("POST /api/webhooks/grafana", handler.RequireWebhookScope(config.ScopeSend,
+		handler.VerifySignature(cfg.Webhooks.Grafana, handler.HandleGrafanaWebhook)))
+	mux.HandleFunc("GET /api/tasks/{id}", handler.RequireScope(config.ScopeSend, handler.GetTask))
+	mux.HandleFunc("GET /api/scheduled", handler.RequireScope(config.ScopeAdmin, handler.ListScheduled))
+	mux.HandleFunc("DELETE /api/scheduled/{id}", handler.RequireScope(config.ScopeAdmin, handler.CancelScheduled))
+	mux.HandleFunc("GET /api/deadletters", handler.RequireScope(config.ScopeAdmin, handler.ListDeadLetters))
+	mux.HandleFunc("POST /api/deadletters/{id}/replay", handler.RequireScope(config.ScopeAdmin, handler.ReplayDeadLetter))
+	mux.HandleFunc("DELETE /api/deadletters/{id}", handler.RequireScope(config.ScopeAdmin, handler.DeleteDeadLetter))
+	mux.HandleFunc("GET /api/admin/breakers", handler.RequireScope(config.ScopeAdmin, handler.ListBreakers))
+	mux.Handle("GET /metrics", metrics.Handler())
+	mux.HandleFunc("GET /healthz", handler.Healthz)
+	mux.HandleFunc("GET /readyz", handler.Readyz)
 
 	// Graceful shutdown
 	go func() {